@@ -0,0 +1,114 @@
+package authcli
+
+import (
+	"path/filepath"
+
+	"codex-control/internal/auth"
+	"codex-control/internal/fsx"
+	"codex-control/internal/logger"
+)
+
+type exportResult struct {
+	Archive   string   `json:"archive"`
+	Profiles  []string `json:"profiles"`
+	Encrypted bool     `json:"encrypted"`
+}
+
+type importReport struct {
+	Archive  string              `json:"archive"`
+	Root     string              `json:"root"`
+	Profiles []auth.ImportResult `json:"profiles"`
+}
+
+// runExport writes the named profiles (all when none are given) with their
+// metadata and usage data to a single archive.
+func (a *app) runExport(args []string) int {
+	if len(args) == 0 {
+		a.Log.Errorf(logger.PrefixCLI, "Usage: codex-auth export <archive> [profile...] [--encrypt]")
+		return 1
+	}
+	archive, names := args[0], args[1:]
+	files, err := a.listFiles()
+	if err != nil {
+		a.Log.Errorf(logger.PrefixAuth, "Failed to list auth files: %v", err)
+		return 1
+	}
+	targets := files
+	if len(names) > 0 {
+		targets = make([]auth.File, 0, len(names))
+		for _, name := range names {
+			file, code := a.matchProfile(files, name)
+			if code != 0 {
+				return code
+			}
+			targets = append(targets, file)
+		}
+	}
+	bundle, err := auth.ExportBundle(targets, a.Tracker)
+	if err != nil {
+		a.Log.Errorf(logger.PrefixAuth, "Failed to collect profiles: %v", err)
+		return 1
+	}
+	raw, err := bundle.Marshal()
+	if err != nil {
+		a.Log.Errorf(logger.PrefixAuth, "Failed to build archive: %v", err)
+		return 1
+	}
+	if a.encryptBundle {
+		secret, err := a.Passphrase.Func(true)()
+		if err != nil {
+			a.Log.Errorf(logger.PrefixAuth, "Failed to read passphrase: %v", err)
+			return 1
+		}
+		if raw, err = auth.Encrypt(raw, secret); err != nil {
+			a.Log.Errorf(logger.PrefixAuth, "Failed to encrypt archive: %v", err)
+			return 1
+		}
+	}
+	if err := fsx.WriteFile(archive, raw, 0o600); err != nil {
+		a.Log.Errorf(logger.PrefixAuth, "Failed to write %s: %v", archive, err)
+		return 1
+	}
+	path, _ := filepath.Abs(archive)
+	return a.print(exportResult{Archive: path, Profiles: bundle.Manifest.Profiles, Encrypted: a.encryptBundle})
+}
+
+// runImport merges an exported archive into the auth directory.
+func (a *app) runImport(args []string) int {
+	if len(args) != 1 {
+		a.Log.Errorf(logger.PrefixCLI, "Usage: codex-auth import <archive> [--on-conflict skip|rename|overwrite]")
+		return 1
+	}
+	policy, err := auth.ParseConflictPolicy(a.onConflict)
+	if err != nil {
+		a.Log.Errorf(logger.PrefixCLI, "%v", err)
+		return 1
+	}
+	raw, err := auth.ReadBundleFile(args[0])
+	if err != nil {
+		a.Log.Errorf(logger.PrefixAuth, "Failed to read archive: %v", err)
+		return 1
+	}
+	if auth.IsEncrypted(raw) {
+		secret, err := a.Passphrase.Func(false)()
+		if err != nil {
+			a.Log.Errorf(logger.PrefixAuth, "Failed to read passphrase: %v", err)
+			return 1
+		}
+		if raw, err = auth.Decrypt(raw, secret); err != nil {
+			a.Log.Errorf(logger.PrefixAuth, "Failed to decrypt archive: %v", err)
+			return 1
+		}
+	}
+	bundle, err := auth.ParseBundle(raw)
+	if err != nil {
+		a.Log.Errorf(logger.PrefixAuth, "Failed to read archive: %v", err)
+		return 1
+	}
+	results, err := auth.ImportBundle(a.Root, bundle, policy, a.Tracker)
+	if err != nil {
+		a.Log.Errorf(logger.PrefixAuth, "Import failed: %v", err)
+		return 1
+	}
+	return a.print(importReport{Archive: args[0], Root: a.Root, Profiles: results})
+}
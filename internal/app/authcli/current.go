@@ -0,0 +1,38 @@
+package authcli
+
+import (
+	"fmt"
+
+	"codex-control/internal/auth"
+	"codex-control/internal/logger"
+)
+
+// unknownProfile is printed by current when the live auth.json matches no
+// stored profile.
+const unknownProfile = "unknown/unsaved"
+
+// runCurrent prints the stem of the profile the live auth.json belongs to as
+// plain text, so it can be embedded in shell prompts. Nothing is decrypted or
+// synced to keep it fast.
+func (a *app) runCurrent(args []string) int {
+	if len(args) != 0 {
+		a.Log.Errorf(logger.PrefixCLI, "Usage: codex-auth current")
+		return 1
+	}
+	files, err := auth.ListRoots(a.Roots)
+	if err != nil {
+		a.Log.Errorf(logger.PrefixAuth, "Failed to list auth files: %v", err)
+		return 1
+	}
+	match, ok, err := auth.FindActive(files)
+	if err != nil {
+		a.Log.Errorf(logger.PrefixAuth, "Failed to inspect live auth.json: %v", err)
+		return 1
+	}
+	if !ok {
+		fmt.Println(unknownProfile)
+		return exitNoMatch
+	}
+	fmt.Println(match.File.Stem())
+	return 0
+}
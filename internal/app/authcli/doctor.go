@@ -0,0 +1,50 @@
+package authcli
+
+import (
+	"codex-control/internal/auth"
+	"codex-control/internal/logger"
+)
+
+type doctorReport struct {
+	Issues    []auth.Issue `json:"issues"`
+	Fixed     int          `json:"fixed"`
+	Remaining int          `json:"remaining"`
+}
+
+// runDoctor reports profiles that fail validation and credential files or
+// folders readable by other users. With --fix it tightens the permissions.
+func (a *app) runDoctor(args []string) int {
+	if len(args) != 0 {
+		a.Log.Errorf(logger.PrefixCLI, "Usage: codex-auth doctor [--fix]")
+		return 1
+	}
+	issues, err := auth.Diagnose(a.Roots)
+	if err != nil {
+		a.Log.Errorf(logger.PrefixAuth, "Failed to inspect auth files: %v", err)
+		return 1
+	}
+	report := doctorReport{Issues: issues}
+	if report.Issues == nil {
+		report.Issues = []auth.Issue{}
+	}
+	for i := range report.Issues {
+		issue := &report.Issues[i]
+		if a.fix && issue.Fixable() {
+			if err := issue.Fix(); err != nil {
+				a.Log.Errorf(logger.PrefixAuth, "Failed to fix %s: %v", issue.Path, err)
+			}
+		}
+		if issue.Fixed {
+			report.Fixed++
+		} else {
+			report.Remaining++
+		}
+	}
+	if code := a.print(report); code != 0 {
+		return code
+	}
+	if report.Remaining > 0 {
+		return exitUnhealthy
+	}
+	return 0
+}
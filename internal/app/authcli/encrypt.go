@@ -0,0 +1,59 @@
+package authcli
+
+import (
+	"codex-control/internal/auth"
+	"codex-control/internal/logger"
+)
+
+type cryptResult struct {
+	Profile string `json:"profile"`
+	Path    string `json:"path"`
+	Changed bool   `json:"changed"`
+}
+
+// runEncrypt converts plaintext profiles to the encrypted format.
+func (a *app) runEncrypt(args []string) int {
+	return a.convertProfiles(args, true)
+}
+
+// runDecrypt converts encrypted profiles back to plaintext.
+func (a *app) runDecrypt(args []string) int {
+	return a.convertProfiles(args, false)
+}
+
+// convertProfiles encrypts or decrypts the named profiles, or every profile
+// when no names are given.
+func (a *app) convertProfiles(names []string, encrypt bool) int {
+	files, err := a.listFiles()
+	if err != nil {
+		a.Log.Errorf(logger.PrefixAuth, "Failed to list auth files: %v", err)
+		return 1
+	}
+	targets := files
+	if len(names) > 0 {
+		targets = make([]auth.File, 0, len(names))
+		for _, name := range names {
+			file, code := a.matchProfile(files, name)
+			if code != 0 {
+				return code
+			}
+			targets = append(targets, file)
+		}
+	}
+	passphrase := a.Passphrase.Func(encrypt)
+	results := make([]cryptResult, 0, len(targets))
+	for _, file := range targets {
+		var changed bool
+		if encrypt {
+			changed, err = auth.EncryptProfile(file.Path, passphrase)
+		} else {
+			changed, err = auth.DecryptProfile(file.Path, passphrase)
+		}
+		if err != nil {
+			a.Log.Errorf(logger.PrefixAuth, "Failed to convert %s: %v", file.Name, err)
+			return 1
+		}
+		results = append(results, cryptResult{Profile: file.Name, Path: file.Path, Changed: changed})
+	}
+	return a.print(results)
+}
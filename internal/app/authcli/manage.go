@@ -0,0 +1,214 @@
+package authcli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"codex-control/internal/auth"
+	"codex-control/internal/logger"
+	"codex-control/internal/tui/menu"
+)
+
+// metadataTemplate seeds the editor for profiles without a sidecar.
+const metadataTemplate = `# Metadata for %s. Leave the file empty to remove it.
+label: ""
+notes: ""
+owner: ""
+tags: []
+color: ""
+disabled: false
+`
+
+// manageActions returns the menu actions that rename, duplicate, delete,
+// reveal and annotate profiles. Each one asks for confirmation before it
+// changes anything and reports through the status line.
+func (a *app) manageActions() []menu.Action {
+	return []menu.Action{
+		{Label: "Rename profile", Exec: withFile(a.renameCmd)},
+		{Label: "Duplicate profile", Exec: withFile(a.duplicateCmd)},
+		{Label: "Delete profile (move to trash)", Exec: withFile(a.trashCmd)},
+		{Label: "Reveal path", Exec: withFile(a.revealCmd)},
+		{Label: "Edit metadata", Exec: withFile(a.editMetadataCmd)},
+	}
+}
+
+func withFile(fn func(auth.File) tea.Cmd) func(menu.Entry) tea.Cmd {
+	return func(entry menu.Entry) tea.Cmd {
+		file, ok := entry.Payload.(auth.File)
+		if !ok {
+			return statusCmd("Invalid selection payload")
+		}
+		return fn(file)
+	}
+}
+
+func statusCmd(text string) tea.Cmd {
+	return func() tea.Msg {
+		return menu.Status(text)
+	}
+}
+
+// renameCmd renames the profile and moves its usage data and history along.
+func (a *app) renameCmd(file auth.File) tea.Cmd {
+	return menu.Prompt(fmt.Sprintf("New name for %s:", file.Name), file.Stem(), func(name string) tea.Cmd {
+		target, err := auth.ProfileFileName(name)
+		if err != nil {
+			return statusCmd(fmt.Sprintf("Rename failed: %v", err))
+		}
+		if target == filepath.Base(file.Path) {
+			return statusCmd("Name unchanged")
+		}
+		return menu.Confirm(fmt.Sprintf("Rename %s to %s?", file.Name, target), tea.Sequence(func() tea.Msg {
+			dest, err := auth.RenameProfile(file, name)
+			if err != nil {
+				return menu.Status(fmt.Sprintf("Rename failed: %v", err))
+			}
+			renamed := strings.TrimSuffix(file.Name, filepath.Base(file.Path)) + filepath.Base(dest)
+			moved := file
+			moved.Path = dest
+			if err := a.Tracker.Rename(a.Tracker.Key(file), a.Tracker.Key(moved)); err != nil {
+				return menu.Status(fmt.Sprintf("Renamed to %s but failed to move usage data: %v", renamed, err))
+			}
+			return menu.Status(fmt.Sprintf("Renamed %s to %s", file.Name, renamed))
+		}, menu.Refresh))
+	})
+}
+
+// duplicateCmd copies the profile and its metadata under a new name.
+func (a *app) duplicateCmd(file auth.File) tea.Cmd {
+	return menu.Prompt(fmt.Sprintf("Name for the copy of %s:", file.Name), file.Stem()+"-copy", func(name string) tea.Cmd {
+		target, err := auth.ProfileFileName(name)
+		if err != nil {
+			return statusCmd(fmt.Sprintf("Duplicate failed: %v", err))
+		}
+		return menu.Confirm(fmt.Sprintf("Copy %s to %s?", file.Name, target), tea.Sequence(func() tea.Msg {
+			dest, err := auth.DuplicateProfile(file, name)
+			if err != nil {
+				return menu.Status(fmt.Sprintf("Duplicate failed: %v", err))
+			}
+			return menu.Status(fmt.Sprintf("Copied %s to %s", file.Name, filepath.Base(dest)))
+		}, menu.Refresh))
+	})
+}
+
+// trashCmd moves the profile into the trash folder of the auths directory.
+func (a *app) trashCmd(file auth.File) tea.Cmd {
+	return menu.Confirm(fmt.Sprintf("Move %s to the trash folder?", file.Name), tea.Sequence(func() tea.Msg {
+		dest, err := auth.TrashProfile(file)
+		if err != nil {
+			return menu.Status(fmt.Sprintf("Delete failed: %v", err))
+		}
+		if err := a.Tracker.Forget(a.Tracker.Key(file)); err != nil {
+			return menu.Status(fmt.Sprintf("Moved to %s but failed to update usage data: %v", dest, err))
+		}
+		return menu.Status(fmt.Sprintf("Moved %s to %s; codex-auth untrash %s brings it back", file.Name, dest, file.Stem()))
+	}, menu.Refresh))
+}
+
+// runUntrash moves the newest trashed copy of a profile back into place.
+func (a *app) runUntrash(args []string) int {
+	if len(args) != 1 {
+		a.Log.Errorf(logger.PrefixCLI, "Usage: codex-auth untrash <profile>")
+		return 1
+	}
+	trashed, err := auth.FindTrashed(a.Roots, args[0])
+	if err != nil {
+		a.Log.Errorf(logger.PrefixAuth, "Cannot restore %q: %v", args[0], err)
+		return exitNoMatch
+	}
+	dest, err := auth.RestoreTrashed(trashed)
+	if err != nil {
+		a.Log.Errorf(logger.PrefixAuth, "Failed to restore %s: %v", trashed, err)
+		return 1
+	}
+	return a.print(auth.CopyResult{Source: trashed, Destination: dest})
+}
+
+// revealCmd shows where the profile lives and offers to open its folder.
+func (a *app) revealCmd(file auth.File) tea.Cmd {
+	opener := fileOpener()
+	if opener == "" {
+		return statusCmd(fmt.Sprintf("Path: %s", file.Path))
+	}
+	dir := filepath.Dir(file.Path)
+	return menu.Confirm(fmt.Sprintf("%s\nOpen %s with %s?", file.Path, dir, opener), func() tea.Msg {
+		if err := exec.Command(opener, dir).Start(); err != nil {
+			return menu.Status(fmt.Sprintf("Failed to open %s: %v", dir, err))
+		}
+		return menu.Status(fmt.Sprintf("Path: %s", file.Path))
+	})
+}
+
+func fileOpener() string {
+	name := "xdg-open"
+	if runtime.GOOS == "darwin" {
+		name = "open"
+	}
+	if _, err := exec.LookPath(name); err != nil {
+		return ""
+	}
+	return name
+}
+
+// editMetadataCmd opens the metadata sidecar in $VISUAL or $EDITOR through a
+// scratch copy and saves it once the change is confirmed.
+func (a *app) editMetadataCmd(file auth.File) tea.Cmd {
+	current, err := os.ReadFile(auth.MetadataPath(file.Path))
+	if errors.Is(err, os.ErrNotExist) {
+		current, err = []byte(fmt.Sprintf(metadataTemplate, file.Name)), nil
+	}
+	if err != nil {
+		return statusCmd(fmt.Sprintf("Failed to read metadata: %v", err))
+	}
+	scratch, err := os.CreateTemp("", "codex-auth-meta-*.yaml")
+	if err != nil {
+		return statusCmd(fmt.Sprintf("Failed to prepare editor: %v", err))
+	}
+	scratchPath := scratch.Name()
+	_, err = scratch.Write(current)
+	if closeErr := scratch.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(scratchPath)
+		return statusCmd(fmt.Sprintf("Failed to prepare editor: %v", err))
+	}
+	command := append(editorCommand(), scratchPath)
+	return tea.ExecProcess(exec.Command(command[0], command[1:]...), func(err error) tea.Msg {
+		defer os.Remove(scratchPath)
+		if err != nil {
+			return menu.Status(fmt.Sprintf("Editor failed: %v", err))
+		}
+		edited, err := os.ReadFile(scratchPath)
+		if err != nil {
+			return menu.Status(fmt.Sprintf("Failed to read edited metadata: %v", err))
+		}
+		if bytes.Equal(edited, current) {
+			return menu.Status("Metadata unchanged")
+		}
+		return menu.Confirm(fmt.Sprintf("Save the edited metadata of %s?", file.Name), tea.Sequence(func() tea.Msg {
+			if err := auth.WriteMetadata(file.Path, edited); err != nil {
+				return menu.Status(fmt.Sprintf("Metadata not saved: %v", err))
+			}
+			return menu.Status(fmt.Sprintf("Saved metadata of %s", file.Name))
+		}, menu.Refresh))()
+	})
+}
+
+// editorCommand splits $VISUAL or $EDITOR, falling back to vi.
+func editorCommand() []string {
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if fields := strings.Fields(os.Getenv(name)); len(fields) > 0 {
+			return fields
+		}
+	}
+	return []string{"vi"}
+}
@@ -0,0 +1,49 @@
+package authcli
+
+import (
+	"codex-control/internal/auth"
+	"codex-control/internal/logger"
+	"codex-control/internal/profiles"
+)
+
+// runAuto installs the profile bound to the working directory.
+func (a *app) runAuto() int {
+	binding, ok, err := profiles.CurrentBinding()
+	if err != nil {
+		a.Log.Errorf(logger.PrefixAuth, "Failed to read %s: %v", auth.ProjectFileName, err)
+		return 1
+	}
+	if !ok {
+		a.Log.Errorf(logger.PrefixAuth, "No %s found in the current directory or its parents", auth.ProjectFileName)
+		return exitNoMatch
+	}
+	files, err := a.listFiles()
+	if err != nil {
+		a.Log.Errorf(logger.PrefixAuth, "Failed to list auth files: %v", err)
+		return 1
+	}
+	file, err := binding.Resolve(files)
+	if code := a.matchFailure(binding.Profile, err); code != 0 {
+		return code
+	}
+	result, err := a.InstallBound(binding, file, "codex-auth --auto")
+	if err != nil {
+		a.Log.Errorf(logger.PrefixAuth, "Failed to install %s: %v", file.Name, err)
+		return 1
+	}
+	return a.print(result)
+}
+
+// boundProfile returns the name of the file bound by .codex-profile, or an
+// empty string when there is no usable binding.
+func boundProfile(files []auth.File) string {
+	binding, ok, err := profiles.CurrentBinding()
+	if err != nil || !ok {
+		return ""
+	}
+	file, err := binding.Resolve(files)
+	if err != nil {
+		return ""
+	}
+	return file.Name
+}
@@ -0,0 +1,150 @@
+package authcli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"codex-control/internal/auth"
+	"codex-control/internal/logger"
+	"codex-control/internal/tui/menu"
+)
+
+// runUndo restores the newest backup of the live auth.json.
+func (a *app) runUndo(args []string) int {
+	if len(args) != 0 {
+		a.Log.Errorf(logger.PrefixCLI, "Usage: codex-auth undo")
+		return 1
+	}
+	backups, err := auth.ListBackups()
+	if err != nil {
+		a.Log.Errorf(logger.PrefixAuth, "Failed to list backups: %v", err)
+		return 1
+	}
+	if len(backups) == 0 {
+		a.Log.Errorf(logger.PrefixAuth, "No auth.json backups to restore")
+		return 1
+	}
+	return a.restore(backups[0])
+}
+
+// runRestore restores the named backup or lets the user pick one.
+func (a *app) runRestore(args []string) int {
+	if len(args) > 1 {
+		a.Log.Errorf(logger.PrefixCLI, "Usage: codex-auth restore [backup]")
+		return 1
+	}
+	backups, err := auth.ListBackups()
+	if err != nil {
+		a.Log.Errorf(logger.PrefixAuth, "Failed to list backups: %v", err)
+		return 1
+	}
+	if len(backups) == 0 {
+		a.Log.Errorf(logger.PrefixAuth, "No auth.json backups to restore")
+		return 1
+	}
+	if len(args) == 1 {
+		backup, err := auth.FindBackup(backups, args[0])
+		if err != nil {
+			a.Log.Errorf(logger.PrefixAuth, "Cannot restore %q: %v", args[0], err)
+			return exitNoMatch
+		}
+		return a.restore(backup)
+	}
+	return a.runRestoreMenu()
+}
+
+func (a *app) restore(backup auth.Backup) int {
+	result, err := a.restoreBackup(backup)
+	if err != nil {
+		a.Log.Errorf(logger.PrefixAuth, "Failed to restore %s: %v", backup.Name, err)
+		return 1
+	}
+	return a.print(result)
+}
+
+// restoreBackup writes refreshed tokens of the installed profile back before
+// the backup replaces the live auth.json and clears the install record.
+func (a *app) restoreBackup(backup auth.Backup) (auth.CopyResult, error) {
+	a.SyncBeforeSwitch()
+	return auth.RestoreBackup(backup, a.Install)
+}
+
+func (a *app) runRestoreMenu() int {
+	cfg := menu.Config{
+		Context:          a.ctx,
+		ListTitle:        "auth.json backups",
+		ListHelp:         []string{"Use ↑/↓ or digits + Enter to highlight a backup.", "Press R to rescan, Ctrl+C to abort."},
+		ActionsTitle:     "Backup actions",
+		ActionsHelp:      []string{"Enter copies the highlighted backup to the live auth.json.", "Esc returns to the backup list."},
+		PanelPlaceholder: "Selections show restore results here.",
+		Loader:           loadBackupEntries,
+		DisablePanel:     true,
+	}
+	cfg.Actions = []menu.Action{
+		{
+			Label: "Restore backup",
+			Exec: func(entry menu.Entry) tea.Cmd {
+				backup, ok := entry.Payload.(auth.Backup)
+				if !ok {
+					return func() tea.Msg {
+						return menu.PanelUpdate("Restore backup", "Invalid selection payload", nil, fmt.Errorf("invalid payload"))
+					}
+				}
+				return tea.Sequence(a.runRestoreCmd(backup), tea.Quit)
+			},
+		},
+	}
+
+	result, err := menu.Start(cfg)
+	if err != nil {
+		a.Log.Errorf(logger.PrefixMenu, "Menu failed: %v", err)
+		return 1
+	}
+	if !result.Success {
+		a.Log.Errorf(logger.PrefixMenu, "Operation cancelled before restoring a backup")
+		return 1
+	}
+	copyResult, ok := result.ActionPayload.(auth.CopyResult)
+	if !ok {
+		a.Log.Errorf(logger.PrefixMenu, "Unexpected action payload type")
+		return 1
+	}
+	return a.print(copyResult)
+}
+
+func loadBackupEntries(_ context.Context) ([]menu.Entry, error) {
+	backups, err := auth.ListBackups()
+	if err != nil {
+		return nil, err
+	}
+	if len(backups) == 0 {
+		return nil, fmt.Errorf("no auth.json backups found")
+	}
+	entries := make([]menu.Entry, 0, len(backups))
+	for _, backup := range backups {
+		parts := []string{formatAuthTimestamp(backup.CreatedAt)}
+		if backup.Identity.Email != "" {
+			parts = append(parts, backup.Identity.Email)
+		}
+		entries = append(entries, menu.Entry{
+			Title:       backup.Name,
+			Description: strings.Join(parts, " • "),
+			Payload:     backup,
+		})
+	}
+	return entries, nil
+}
+
+func (a *app) runRestoreCmd(backup auth.Backup) tea.Cmd {
+	return func() tea.Msg {
+		result, err := a.restoreBackup(backup)
+		if err != nil {
+			return menu.PanelUpdate("Restore backup", err.Error(), result, err)
+		}
+		content := fmt.Sprintf("Restored %s to %s", backup.Name, result.Destination)
+		return menu.PanelUpdate("Restore backup", content, result, nil)
+	}
+}
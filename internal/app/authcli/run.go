@@ -6,7 +6,7 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
-	"sort"
+	"strings"
 	"syscall"
 	"time"
 
@@ -14,15 +14,21 @@ import (
 
 	"codex-control/internal/auth"
 	"codex-control/internal/cli"
-	"codex-control/internal/config"
 	"codex-control/internal/logger"
 	"codex-control/internal/output"
+	"codex-control/internal/profiles"
 	"codex-control/internal/tui/menu"
 )
 
-type authConfig struct {
-	Verbosity int    `yaml:"verbosity"`
-	AuthsPath string `yaml:"auths-path"`
+const command = "codex-auth"
+
+// commandFlags names the command each command-specific flag belongs to.
+var commandFlags = map[string]string{
+	"encrypt":     "export",
+	"on-conflict": "import",
+	"fix":         "doctor",
+	"period":      "stats",
+	"format":      "stats",
 }
 
 // Run executes the codex-auth workflow.
@@ -30,13 +36,11 @@ func Run(args []string) int {
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
-	const command = "codex-auth"
-	const synopsis = "codex-auth [options]"
+	const synopsis = "codex-auth [options] [command]"
 
 	log := logger.New()
-	defaults := authConfig{Verbosity: 1, AuthsPath: ""}
-	var settings authConfig
-	if _, err := config.Load(command, defaults, &settings); err != nil {
+	settings, err := profiles.LoadConfig()
+	if err != nil {
 		log.Errorf(logger.PrefixCLI, "Failed to load config: %v", err)
 		return 1
 	}
@@ -47,29 +51,129 @@ func Run(args []string) int {
 	global := cli.GlobalFlags{}
 	global.Register(fs, settings.Verbosity)
 
-	var authPathFlag string
-	fs.StringVar(&authPathFlag, "auths-path", settings.AuthsPath, "Folder containing Codex auth profiles.")
-
-	options := append(cli.GlobalUsageOptions(), cli.UsageOption{
-		Long:        "auths-path",
-		Short:       "a",
-		Value:       "<path>",
-		Description: "Override the configured auths path for this run.",
+	authsFlagSet := false
+	fs.Func("auths-path", "Folder containing Codex auth profiles (repeatable).", func(value string) error {
+		if !authsFlagSet {
+			settings.AuthsPath = nil
+			authsFlagSet = true
+		}
+		settings.AuthsPath = append(settings.AuthsPath, value)
+		return nil
 	})
+	var force bool
+	fs.BoolVar(&force, "force", false, "Overwrite existing profiles.")
+	fs.StringVar(&settings.PassphraseFile, "passphrase-file", settings.PassphraseFile, "File holding the profile passphrase.")
+	fs.StringVar(&settings.CodexHome, "codex-home", settings.CodexHome, "Codex home directory to install into.")
+	fs.BoolVar(&settings.Isolate, "isolate", settings.Isolate, "Install each profile into its own Codex home.")
+	var autoInstall bool
+	fs.BoolVar(&autoInstall, "auto", false, "Install the profile bound by .codex-profile.")
+	var tagFlag string
+	fs.StringVar(&tagFlag, "tag", "", "Only consider profiles carrying these comma-separated tags.")
+	var encryptBundle bool
+	fs.BoolVar(&encryptBundle, "encrypt", false, "Encrypt the exported archive.")
+	var onConflict string
+	fs.StringVar(&onConflict, "on-conflict", string(auth.ConflictSkip), "How import handles existing profiles.")
+	var fix bool
+	fs.BoolVar(&fix, "fix", false, "Let doctor repair file permissions.")
+	var statsPeriod, statsFormat string
+	fs.StringVar(&statsPeriod, "period", "day", "Bucket size for stats.")
+	fs.StringVar(&statsFormat, "format", "table", "Output format for stats.")
+
+	options := append(cli.GlobalUsageOptions(),
+		cli.UsageOption{
+			Long:        "auths-path",
+			Short:       "a",
+			Value:       "<path>",
+			Description: "Override the configured auths path for this run; repeat it to scan several folders.",
+		},
+		cli.UsageOption{
+			Long:        "auto",
+			Description: "Install the profile named by the nearest .codex-profile file and exit.",
+		},
+		cli.UsageOption{
+			Long:        "codex-home",
+			Value:       "<path>",
+			Description: "Install into this Codex home instead of $CODEX_HOME or ~/.codex.",
+		},
+		cli.UsageOption{
+			Long:        "isolate",
+			Description: "Install the profile into its own Codex home under homes-path (default ~/.codex-homes).",
+		},
+		cli.UsageOption{
+			Long:        "force",
+			Short:       "f",
+			Description: "Allow save to overwrite an existing profile.",
+		},
+		cli.UsageOption{
+			Long:        "tag",
+			Short:       "t",
+			Value:       "<tag[,tag...]>",
+			Description: "Only list and match profiles whose metadata carries every given tag.",
+		},
+		cli.UsageOption{
+			Long:        "encrypt",
+			Description: "Encrypt the archive written by export with the profile passphrase.",
+		},
+		cli.UsageOption{
+			Long:        "on-conflict",
+			Value:       "<skip|rename|overwrite>",
+			Description: "What import does with profiles that already exist (default skip).",
+		},
+		cli.UsageOption{
+			Long:        "fix",
+			Description: "Make doctor remove group and other access from auth files and folders.",
+		},
+		cli.UsageOption{
+			Long:        "period",
+			Value:       "<day|week>",
+			Description: "Group stats by day (default) or ISO week.",
+		},
+		cli.UsageOption{
+			Long:        "format",
+			Value:       "<table|json>",
+			Description: "Render stats as a table (default) or JSON.",
+		},
+		cli.UsageOption{
+			Long:        "passphrase-file",
+			Value:       "<path>",
+			Description: "Read the passphrase for encrypted profiles from a file (or set CODEX_AUTH_PASSPHRASE).",
+		},
+	)
+	commands := []cli.UsageCommand{
+		{Name: "use", Args: "<profile>", Description: "Install the matching profile without opening the menu."},
+		{Name: "save", Args: "<name>", Description: "Store the live auth.json as a named profile."},
+		{Name: "sync", Description: "Copy refreshed tokens from the live auth.json back to the installed profile."},
+		{Name: "undo", Description: "Restore the auth.json that the last switch replaced."},
+		{Name: "restore", Args: "[backup]", Description: "Restore a backup by name, or pick one from a menu."},
+		{Name: "untrash", Args: "<profile>", Description: "Move the newest deleted copy of a profile out of the trash."},
+		{Name: "encrypt", Args: "[profile...]", Description: "Encrypt profiles at rest (all profiles when none are named)."},
+		{Name: "decrypt", Args: "[profile...]", Description: "Convert encrypted profiles back to plaintext."},
+		{Name: "stats", Description: "Summarize profile switches per day or week."},
+		{Name: "current", Description: "Print the profile the live auth.json belongs to."},
+		{Name: "doctor", Description: "Check profiles for invalid content and loose file permissions."},
+		{Name: "export", Args: "<archive> [profile...]", Description: "Bundle profiles, metadata and usage history into one archive."},
+		{Name: "import", Args: "<archive>", Description: "Merge an exported archive into the auths directory."},
+	}
 	fs.Usage = func() {
-		cli.UsagePrinter{Command: command, Synopsis: synopsis, Options: options}.Print()
+		cli.UsagePrinter{Command: command, Synopsis: synopsis, Commands: commands, Options: options}.Print()
 	}
 
-	leftovers, err := cli.Parse(fs, args, []cli.FlagAlias{
+	positional, err := cli.ParseInterspersed(fs, args, []cli.FlagAlias{
 		{Canonical: "verbosity", Short: "v", HasValue: true},
 		{Canonical: "auths-path", Short: "a", HasValue: true},
+		{Canonical: "force", Short: "f"},
+		{Canonical: "tag", Short: "t", HasValue: true},
 	})
 	if err != nil {
 		log.Errorf(logger.PrefixCLI, "Flag parsing failed: %v", err)
 		return 1
 	}
-	if len(leftovers) > 0 {
-		log.Errorf(logger.PrefixCLI, "Unexpected positional arguments: %v", leftovers)
+	subcommand := ""
+	if len(positional) > 0 {
+		subcommand = positional[0]
+	}
+	if err := cli.CheckCommandFlags(fs, subcommand, commandFlags); err != nil {
+		log.Errorf(logger.PrefixCLI, "Flag parsing failed: %v", err)
 		return 1
 	}
 	if err := cli.ValidateVerbosity(global.Verbosity); err != nil {
@@ -77,27 +181,113 @@ func Run(args []string) int {
 		return 1
 	}
 
-	authPath, err := auth.ValidateRoot(authPathFlag)
-	if err != nil {
-		log.Errorf(logger.PrefixAuth, "Invalid auth directory: %v", err)
+	if err := auth.SetCodexHome(settings.CodexHome); err != nil {
+		log.Errorf(logger.PrefixCLI, "Invalid Codex home: %v", err)
 		return 1
 	}
 
-	tracker, err := auth.LoadUsageTracker(authPath)
+	saveRoot := len(positional) > 0 && (positional[0] == "save" || positional[0] == "import")
+	a, err := newApp(ctx, log, settings, global.Verbosity, saveRoot)
 	if err != nil {
-		log.Errorf(logger.PrefixAuth, "Failed to load auth usage data: %v", err)
+		log.Errorf(logger.PrefixAuth, "%v", err)
 		return 1
 	}
-	loader := &authLoader{root: authPath, tracker: tracker}
+	a.Force = force
+	a.tags = splitList(tagFlag)
+	a.encryptBundle = encryptBundle
+	a.onConflict = onConflict
+	a.fix = fix
+	a.statsPeriod = statsPeriod
+	a.statsFormat = statsFormat
+
+	if autoInstall {
+		if len(positional) > 0 {
+			log.Errorf(logger.PrefixCLI, "--auto cannot be combined with the %q command", positional[0])
+			return 1
+		}
+		return a.runAuto()
+	}
+	if len(positional) > 0 {
+		switch positional[0] {
+		case "use":
+			return a.runUse(positional[1:])
+		case "save":
+			return a.runSave(positional[1:])
+		case "sync":
+			return a.runSync(positional[1:])
+		case "undo":
+			return a.runUndo(positional[1:])
+		case "restore":
+			return a.runRestore(positional[1:])
+		case "untrash":
+			return a.runUntrash(positional[1:])
+		case "encrypt":
+			return a.runEncrypt(positional[1:])
+		case "decrypt":
+			return a.runDecrypt(positional[1:])
+		case "current":
+			return a.runCurrent(positional[1:])
+		case "doctor":
+			return a.runDoctor(positional[1:])
+		case "export":
+			return a.runExport(positional[1:])
+		case "import":
+			return a.runImport(positional[1:])
+		case "stats":
+			return a.runStats(positional[1:])
+		default:
+			log.Errorf(logger.PrefixCLI, "Unknown command %q", positional[0])
+			return 1
+		}
+	}
+	return a.runMenu()
+}
+
+type app struct {
+	*profiles.Switcher
+
+	ctx     context.Context
+	printer output.Printer
+	tags    []string
+
+	encryptBundle bool
+	onConflict    string
+	fix           bool
+
+	statsPeriod string
+	statsFormat string
+}
+
+// newApp resolves the auth directory and usage data for a run. saveRoot
+// accepts an empty auth directory so the first profile can be saved into it.
+func newApp(ctx context.Context, log *logger.Logger, settings profiles.Config, verbosity int, saveRoot bool) (*app, error) {
+	switcher, err := profiles.New(log, settings, saveRoot)
+	if err != nil {
+		return nil, err
+	}
+	return &app{Switcher: switcher, ctx: ctx, printer: output.Printer{Verbosity: verbosity}}, nil
+}
+
+// pendingInstall defers installing an encrypted profile until the menu has
+// released the terminal, so the passphrase prompt can be shown.
+type pendingInstall struct {
+	file auth.File
+}
+
+func (a *app) runMenu() int {
+	loader := &authLoader{roots: a.Roots, tracker: a.Tracker, tags: a.tags}
 	cfg := menu.Config{
-		Context:          ctx,
+		Context:          a.ctx,
 		ListTitle:        "Codex auth profiles",
-		ListHelp:         []string{"Use ↑/↓ or digits + Enter to highlight a profile.", "Press R to rescan the folder, Ctrl+C to abort."},
+		ListHelp:         []string{"Use ↑/↓ or digits + Enter to highlight a profile.", "Changes on disk show up automatically; press R to rescan, T to filter by tag, Ctrl+C to abort."},
 		ActionsTitle:     "Auth actions",
-		ActionsHelp:      []string{"Enter copies the highlighted profile to ~/.codex/auth.json."},
+		ActionsHelp:      []string{"Enter runs the highlighted action against the selected profile.", "Esc returns to the profile list."},
 		PanelPlaceholder: "Selections show copy results here.",
 		Loader:           loader.Load,
 		DisablePanel:     true,
+		Watch: func(ctx context.Context, notify func()) error {
+			return auth.WatchRoots(ctx, a.Roots, notify)
+		},
 	}
 	cfg.Actions = []menu.Action{
 		{
@@ -109,98 +299,241 @@ func Run(args []string) int {
 						return menu.PanelUpdate("Copy auth", "Invalid selection payload", nil, fmt.Errorf("invalid payload"))
 					}
 				}
-				return tea.Sequence(runCopyCmd(authFile, tracker), tea.Quit)
+				if authFile.Invalid != "" {
+					return func() tea.Msg {
+						return menu.PanelUpdate("Copy auth", "Not a valid auth file", nil, fmt.Errorf("%s: %s", authFile.Name, authFile.Invalid))
+					}
+				}
+				if authFile.Meta.Disabled {
+					return func() tea.Msg {
+						return menu.PanelUpdate("Copy auth", "Profile is disabled", nil, fmt.Errorf("%s is disabled in its metadata", authFile.Name))
+					}
+				}
+				if authFile.Encrypted && !a.Passphrase.NonInteractive() {
+					return tea.Sequence(func() tea.Msg {
+						return menu.PanelUpdate("Copy auth", "Passphrase required", pendingInstall{file: authFile}, nil)
+					}, tea.Quit)
+				}
+				return tea.Sequence(a.runCopyCmd(authFile), tea.Quit)
+			},
+		},
+		{
+			Label: "Replace with current auth.json",
+			Exec: func(entry menu.Entry) tea.Cmd {
+				authFile, ok := entry.Payload.(auth.File)
+				if !ok {
+					return func() tea.Msg {
+						return menu.PanelUpdate("Save auth", "Invalid selection payload", nil, fmt.Errorf("invalid payload"))
+					}
+				}
+				question := fmt.Sprintf("Overwrite %s with the live auth.json?", authFile.Name)
+				return menu.Confirm(question, tea.Sequence(a.runSaveCmd(authFile), menu.Refresh))
+			},
+		},
+		{
+			Label: "Save current auth.json as new profile",
+			Exec: func(menu.Entry) tea.Cmd {
+				return a.saveNewCmd()
 			},
 		},
 	}
+	cfg.Actions = append(cfg.Actions, a.manageActions()...)
 
 	result, err := menu.Start(cfg)
 	if err != nil {
-		log.Errorf(logger.PrefixMenu, "Menu failed: %v", err)
+		a.Log.Errorf(logger.PrefixMenu, "Menu failed: %v", err)
 		return 1
 	}
 	if !result.Success {
-		log.Errorf(logger.PrefixMenu, "Operation cancelled before copying an auth file")
+		a.Log.Errorf(logger.PrefixMenu, "Operation cancelled before copying an auth file")
 		return 1
 	}
-	copyResult, ok := result.ActionPayload.(auth.CopyResult)
-	if !ok {
-		log.Errorf(logger.PrefixMenu, "Unexpected action payload type")
+	switch payload := result.ActionPayload.(type) {
+	case auth.CopyResult:
+		return a.print(payload)
+	case pendingInstall:
+		copyResult, err := a.Activate(payload.file, "codex-auth menu")
+		if err != nil {
+			a.Log.Errorf(logger.PrefixAuth, "Failed to install %s: %v", payload.file.Name, err)
+			return 1
+		}
+		return a.print(copyResult)
+	default:
+		a.Log.Errorf(logger.PrefixMenu, "Unexpected action payload type")
 		return 1
 	}
+}
 
-	printer := output.Printer{Verbosity: global.Verbosity}
+// print renders a command result through the shared printer.
+func (a *app) print(payload any) int {
 	envDump := map[string]string{
-		"auths_path": authPath,
+		"auths_path": strings.Join(a.Roots, string(os.PathListSeparator)),
 	}
-	if err := printer.Print(envDump, copyResult); err != nil {
-		log.Errorf(logger.PrefixCLI, "Failed to render output: %v", err)
+	if err := a.printer.Print(envDump, payload); err != nil {
+		a.Log.Errorf(logger.PrefixCLI, "Failed to render output: %v", err)
 		return 1
 	}
 	return 0
 }
 
 type authLoader struct {
-	root    string
+	roots   []string
 	tracker *auth.UsageTracker
+	tags    []string
 }
 
 func (a *authLoader) Load(_ context.Context) ([]menu.Entry, error) {
-	files, err := auth.ListFiles(a.root)
+	files, err := auth.ListRoots(a.roots)
 	if err != nil {
 		return nil, err
 	}
-	if len(files) == 0 {
-		return nil, fmt.Errorf("%s contains no files", a.root)
+	active, _, err := auth.FindActive(files)
+	if err != nil {
+		return nil, err
 	}
-	lastUsed := func(name string) time.Time {
-		if a.tracker == nil {
-			return time.Time{}
+	files = auth.FilterByTags(files, a.tags)
+	if len(files) == 0 {
+		if len(a.tags) > 0 {
+			return nil, fmt.Errorf("%s contains no profiles tagged %s", strings.Join(a.roots, ", "), strings.Join(a.tags, ", "))
 		}
-		return a.tracker.LastUsed(name)
+		return nil, fmt.Errorf("%s contains no files", strings.Join(a.roots, ", "))
 	}
-	sort.SliceStable(files, func(i, j int) bool {
-		ti := lastUsed(files[i].Name)
-		tj := lastUsed(files[j].Name)
-		if ti.IsZero() && tj.IsZero() {
-			return files[i].Name < files[j].Name
+	profiles.SortByLastUsed(files, a.tracker)
+	now := time.Now()
+	project := boundProfile(files)
+	entries := make([]menu.Entry, 0, len(files))
+	for _, file := range files {
+		badges := fileBadges(file, now)
+		if file.Name == project {
+			badges = append([]string{"project"}, badges...)
 		}
-		if ti.IsZero() {
-			return true
+		if file.Path == active.File.Path {
+			badges = append([]string{"active"}, badges...)
 		}
-		if tj.IsZero() {
-			return false
+		if file.Group != "" {
+			badges = append(badges, file.Group)
 		}
-		if !ti.Equal(tj) {
-			return ti.Before(tj)
+		title := file.Name
+		if file.Group != "" {
+			title = strings.Replace(title, file.Group+"/", "", 1)
+		}
+		if file.Meta.Label != "" {
+			title = file.Meta.Label
 		}
-		return files[i].Name < files[j].Name
-	})
-	entries := make([]menu.Entry, 0, len(files))
-	for _, file := range files {
 		entries = append(entries, menu.Entry{
-			Title:       file.Name,
-			Description: fmt.Sprintf("Last used %s", formatAuthTimestamp(lastUsed(file.Name))),
+			Title:       title,
+			Subtitle:    file.Meta.Notes,
+			Description: describeAuthFile(file, a.tracker.LastUsed(a.tracker.Key(file))),
+			Badges:      badges,
+			Tags:        file.Meta.Tags,
+			Color:       file.Meta.Color,
+			Key:         file.Path,
 			Payload:     file,
 		})
 	}
 	return entries, nil
 }
 
-func runCopyCmd(file auth.File, tracker *auth.UsageTracker) tea.Cmd {
+func describeAuthFile(file auth.File, lastUsed time.Time) string {
+	id := file.Identity
+	parts := []string{}
+	if file.Invalid != "" {
+		parts = append(parts, file.Invalid)
+	}
+	if file.Meta.Label != "" {
+		parts = append(parts, file.Name)
+	}
+	if file.Meta.Owner != "" {
+		parts = append(parts, fmt.Sprintf("owner %s", file.Meta.Owner))
+	}
+	if id.Email != "" {
+		parts = append(parts, id.Email)
+	}
+	if id.AccountID != "" {
+		parts = append(parts, fmt.Sprintf("account %s", shortAccountID(id.AccountID)))
+	}
+	if !id.ExpiresAt.IsZero() {
+		parts = append(parts, fmt.Sprintf("token expires %s", formatAuthTimestamp(id.ExpiresAt)))
+	}
+	parts = append(parts, fmt.Sprintf("Last used %s", formatAuthTimestamp(lastUsed)))
+	return strings.Join(parts, " • ")
+}
+
+func fileBadges(file auth.File, now time.Time) []string {
+	badges := identityBadges(file.Identity, now)
+	if file.Encrypted {
+		badges = append([]string{"encrypted"}, badges...)
+	}
+	if file.Meta.Disabled {
+		badges = append([]string{"disabled"}, badges...)
+	}
+	if file.LeasedByOther() {
+		badges = append([]string{"leased by " + file.Lease.Holder}, badges...)
+	}
+	if file.Invalid != "" {
+		badges = append([]string{"invalid"}, badges...)
+	}
+	badges = append(badges, file.Meta.Tags...)
+	return badges
+}
+
+func identityBadges(id auth.Identity, now time.Time) []string {
+	badges := []string{}
+	if id.Expired(now) {
+		badges = append(badges, "expired")
+	}
+	if id.APIKey {
+		badges = append(badges, "api-key")
+	}
+	if id.Provider != "" {
+		badges = append(badges, id.Provider)
+	}
+	if id.Plan != "" {
+		badges = append(badges, strings.ToLower(id.Plan))
+	}
+	return badges
+}
+
+func shortAccountID(id string) string {
+	if len(id) <= 8 {
+		return id
+	}
+	return id[:8]
+}
+
+func (a *app) runCopyCmd(file auth.File) tea.Cmd {
 	return func() tea.Msg {
-		result, err := auth.Install(file.Path)
+		result, err := a.Activate(file, "codex-auth menu")
 		if err != nil {
 			return menu.PanelUpdate("Copy auth", err.Error(), result, err)
 		}
-		if tracker != nil {
-			_ = tracker.Touch(file.Name, time.Now())
-		}
 		content := fmt.Sprintf("Copied %s to %s", file.Name, result.Destination)
 		return menu.PanelUpdate("Copy auth", content, result, nil)
 	}
 }
 
+func (a *app) runSaveCmd(file auth.File) tea.Cmd {
+	return func() tea.Msg {
+		result, err := a.replace(file)
+		if err != nil {
+			return menu.PanelUpdate("Save auth", err.Error(), result, err)
+		}
+		content := fmt.Sprintf("Saved %s to %s", result.Source, file.Name)
+		return menu.PanelUpdate("Save auth", content, result, nil)
+	}
+}
+
+// splitList parses a comma-separated flag value, dropping empty items.
+func splitList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func formatAuthTimestamp(t time.Time) string {
 	if t.IsZero() {
 		return "never used"
@@ -0,0 +1,77 @@
+package authcli
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"codex-control/internal/auth"
+	"codex-control/internal/logger"
+	"codex-control/internal/tui/menu"
+)
+
+// runSave snapshots the live credentials into the auth directory.
+func (a *app) runSave(args []string) int {
+	if len(args) != 1 {
+		a.Log.Errorf(logger.PrefixCLI, "Usage: codex-auth save <name> [--force]")
+		return 1
+	}
+	result, err := a.save(args[0], a.Force)
+	if err != nil {
+		if errors.Is(err, auth.ErrProfileExists) {
+			a.Log.Errorf(logger.PrefixAuth, "%v (use --force to overwrite)", err)
+			return 1
+		}
+		a.Log.Errorf(logger.PrefixAuth, "Failed to save profile: %v", err)
+		return 1
+	}
+	return a.print(result)
+}
+
+// save stores the live auth.json as the named profile and marks it as just
+// used, since it now mirrors the installed credentials.
+func (a *app) save(name string, force bool) (auth.CopyResult, error) {
+	result, err := auth.Save(a.Root, name, force, a.Install)
+	if err != nil {
+		return result, err
+	}
+	a.recordSave(auth.File{Root: a.Root, Path: result.Destination})
+	return result, nil
+}
+
+// saveNewCmd asks for a name and stores the live auth.json under it, like
+// codex-auth save without --force: an existing profile is never replaced.
+func (a *app) saveNewCmd() tea.Cmd {
+	return menu.Prompt("Name for the new profile:", "", func(name string) tea.Cmd {
+		return tea.Sequence(func() tea.Msg {
+			result, err := a.save(name, false)
+			if errors.Is(err, auth.ErrProfileExists) {
+				return menu.Status(fmt.Sprintf("Save failed: %v (pick another name or use Replace)", err))
+			}
+			if err != nil {
+				return menu.Status(fmt.Sprintf("Save failed: %v", err))
+			}
+			return menu.Status(fmt.Sprintf("Saved the live auth.json as %s", filepath.Base(result.Destination)))
+		}, menu.Refresh)
+	})
+}
+
+// replace overwrites the existing profile file with the live auth.json,
+// wherever it is stored.
+func (a *app) replace(file auth.File) (auth.CopyResult, error) {
+	result, err := auth.SaveTo(file.Path, true, a.Install)
+	if err != nil {
+		return result, err
+	}
+	a.recordSave(file)
+	return result, nil
+}
+
+func (a *app) recordSave(file auth.File) {
+	if a.Tracker != nil {
+		_ = a.Tracker.Record(a.Tracker.Key(file), "codex-auth save", time.Now())
+	}
+}
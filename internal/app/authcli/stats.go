@@ -0,0 +1,103 @@
+package authcli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"codex-control/internal/auth"
+	"codex-control/internal/logger"
+)
+
+// statsRow counts the switches to one profile within one period.
+type statsRow struct {
+	Period   string `json:"period"`
+	Profile  string `json:"profile"`
+	Switches int    `json:"switches"`
+}
+
+// statsReport summarizes the switch history.
+type statsReport struct {
+	Granularity string         `json:"granularity"`
+	Rows        []statsRow     `json:"rows"`
+	Totals      map[string]int `json:"totals"`
+}
+
+// runStats prints switch counts per profile per day or week.
+func (a *app) runStats(args []string) int {
+	if len(args) != 0 {
+		a.Log.Errorf(logger.PrefixCLI, "Usage: codex-auth stats [--period day|week] [--format table|json]")
+		return 1
+	}
+	var bucket func(time.Time) string
+	switch a.statsPeriod {
+	case "day":
+		bucket = func(t time.Time) string { return t.Local().Format("2006-01-02") }
+	case "week":
+		bucket = func(t time.Time) string {
+			year, week := t.Local().ISOWeek()
+			return fmt.Sprintf("%d-W%02d", year, week)
+		}
+	default:
+		a.Log.Errorf(logger.PrefixCLI, "Invalid period %q: use day or week", a.statsPeriod)
+		return 1
+	}
+	if a.statsFormat != "table" && a.statsFormat != "json" {
+		a.Log.Errorf(logger.PrefixCLI, "Invalid format %q: use table or json", a.statsFormat)
+		return 1
+	}
+
+	history, err := a.Tracker.History()
+	if err != nil {
+		a.Log.Errorf(logger.PrefixAuth, "Failed to read switch history: %v", err)
+		return 1
+	}
+	report := summarizeHistory(history, a.statsPeriod, bucket)
+	if a.statsFormat == "json" {
+		return a.print(report)
+	}
+	if a.printer.Verbosity <= 0 {
+		return 0
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\tPROFILE\tSWITCHES\n", map[string]string{"day": "DAY", "week": "WEEK"}[a.statsPeriod])
+	for _, row := range report.Rows {
+		fmt.Fprintf(w, "%s\t%s\t%d\n", row.Period, row.Profile, row.Switches)
+	}
+	profiles := make([]string, 0, len(report.Totals))
+	for profile := range report.Totals {
+		profiles = append(profiles, profile)
+	}
+	sort.Strings(profiles)
+	for _, profile := range profiles {
+		fmt.Fprintf(w, "total\t%s\t%d\n", profile, report.Totals[profile])
+	}
+	if err := w.Flush(); err != nil {
+		a.Log.Errorf(logger.PrefixCLI, "Failed to render output: %v", err)
+		return 1
+	}
+	return 0
+}
+
+func summarizeHistory(history []auth.HistoryEntry, granularity string, bucket func(time.Time) string) statsReport {
+	type key struct{ period, profile string }
+	counts := map[key]int{}
+	totals := map[string]int{}
+	for _, entry := range history {
+		counts[key{bucket(entry.Time), entry.Profile}]++
+		totals[entry.Profile]++
+	}
+	rows := make([]statsRow, 0, len(counts))
+	for k, n := range counts {
+		rows = append(rows, statsRow{Period: k.period, Profile: k.profile, Switches: n})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Period != rows[j].Period {
+			return rows[i].Period < rows[j].Period
+		}
+		return rows[i].Profile < rows[j].Profile
+	})
+	return statsReport{Granularity: granularity, Rows: rows, Totals: totals}
+}
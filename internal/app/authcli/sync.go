@@ -0,0 +1,27 @@
+package authcli
+
+import (
+	"codex-control/internal/auth"
+	"codex-control/internal/logger"
+)
+
+// runSync writes refreshed live tokens back to the installed profile.
+func (a *app) runSync(args []string) int {
+	if len(args) != 0 {
+		a.Log.Errorf(logger.PrefixCLI, "Usage: codex-auth sync")
+		return 1
+	}
+	result, err := auth.Sync(a.Install)
+	if err != nil {
+		a.Log.Errorf(logger.PrefixAuth, "Sync failed: %v", err)
+		return 1
+	}
+	if code := a.print(result); code != 0 {
+		return code
+	}
+	if result.Status == auth.SyncConflict {
+		a.Log.Errorf(logger.PrefixAuth, "Sync conflict for %s: %s", result.Profile, result.Detail)
+		return exitConflict
+	}
+	return 0
+}
@@ -0,0 +1,98 @@
+package authcli
+
+import (
+	"errors"
+	"strings"
+
+	"codex-control/internal/auth"
+	"codex-control/internal/logger"
+)
+
+// Exit codes reported by the non-interactive profile commands.
+const (
+	exitNoMatch   = 2
+	exitAmbiguous = 3
+	exitConflict  = 4
+	exitLeased    = 5
+	exitUnhealthy = 6
+)
+
+// runUse installs the profile matching args[0] without starting the menu.
+func (a *app) runUse(args []string) int {
+	if len(args) != 1 {
+		a.Log.Errorf(logger.PrefixCLI, "Usage: codex-auth use <profile>")
+		return 1
+	}
+	file, code := a.resolveProfile(args[0])
+	if code != 0 {
+		return code
+	}
+	if file.Meta.Disabled && !a.Force {
+		a.Log.Errorf(logger.PrefixAuth, "Profile %s is disabled (use --force to install it anyway)", file.Name)
+		return 1
+	}
+	result, err := a.Activate(file, "codex-auth use")
+	var held *auth.LeaseHeldError
+	if errors.As(err, &held) {
+		a.Log.Errorf(logger.PrefixAuth, "Profile %v (use --force to take it over)", held)
+		return exitLeased
+	}
+	if err != nil {
+		a.Log.Errorf(logger.PrefixAuth, "Failed to install %s: %v", file.Name, err)
+		return 1
+	}
+	return a.print(result)
+}
+
+// resolveProfile matches a query against the auth directory and maps lookup
+// failures onto the documented exit codes.
+func (a *app) resolveProfile(query string) (auth.File, int) {
+	files, err := a.listFiles()
+	if err != nil {
+		a.Log.Errorf(logger.PrefixAuth, "Failed to list auth files: %v", err)
+		return auth.File{}, 1
+	}
+	return a.matchProfile(files, query)
+}
+
+// listFiles lists the valid profiles of every auths root, narrowed to --tag.
+func (a *app) listFiles() ([]auth.File, error) {
+	files, err := a.ValidFiles()
+	if err != nil {
+		return nil, err
+	}
+	return auth.FilterByTags(files, a.tags), nil
+}
+
+// matchProfile resolves query within files, logging lookup failures.
+func (a *app) matchProfile(files []auth.File, query string) (auth.File, int) {
+	file, err := auth.Match(files, query)
+	return file, a.matchFailure(query, err)
+}
+
+// matchFailure logs a failed profile lookup and maps it onto the documented
+// exit codes. A nil err maps to 0.
+func (a *app) matchFailure(query string, err error) int {
+	if err == nil {
+		return 0
+	}
+	var ambiguous *auth.AmbiguousMatchError
+	switch {
+	case errors.As(err, &ambiguous):
+		names := make([]string, len(ambiguous.Candidates))
+		for i, candidate := range ambiguous.Candidates {
+			names[i] = candidate.Name
+		}
+		a.Log.Errorf(logger.PrefixAuth, "Profile %q is ambiguous: %s", query, strings.Join(names, ", "))
+		return exitAmbiguous
+	case errors.Is(err, auth.ErrBoundProfileNotFound):
+		a.Log.Errorf(logger.PrefixAuth, "Cannot install the project profile: %v", err)
+		return exitNoMatch
+	case errors.Is(err, auth.ErrNoMatch):
+		a.Log.Errorf(logger.PrefixAuth, "No profile matches %q in %s", query, strings.Join(a.Roots, ", "))
+		return exitNoMatch
+	default:
+		a.Log.Errorf(logger.PrefixAuth, "Failed to resolve profile: %v", err)
+		return 1
+	}
+}
@@ -18,6 +18,21 @@ import (
 type updateConfig struct {
 	Verbosity   int    `yaml:"verbosity"`
 	GitHubToken string `yaml:"github-token"`
+	// PinnedDigests maps "<tag>/<archive>" to the expected SHA-256.
+	PinnedDigests map[string]string `yaml:"pinned-digests"`
+	// RequireChecksum refuses releases that publish no checksum; by default
+	// they are installed with a warning.
+	RequireChecksum bool `yaml:"require-checksum"`
+	// SignaturePolicy is off, warn or require; SignatureKeys are the keys
+	// trusted to sign releases.
+	SignaturePolicy string               `yaml:"signature-policy"`
+	SignatureKeys   []codex.SignatureKey `yaml:"signature-keys"`
+	// VersionsPath stores every installed release; empty means
+	// ~/.local/share/codex-control/versions.
+	VersionsPath string `yaml:"versions-path"`
+	KeepVersions int    `yaml:"keep-versions"`
+
+	cli.TargetConfig `yaml:",inline"`
 }
 
 // Run executes the codex-update workflow.
@@ -26,13 +41,13 @@ func Run(args []string) int {
 	defer cancel()
 
 	command := "codex-update"
-	synopsis := "codex-update [options]"
+	synopsis := "codex-update [options] [command]"
 
 	log := logger.New()
 	fs := flag.NewFlagSet(command, flag.ContinueOnError)
 	fs.SetOutput(os.Stderr)
 
-	defaults := updateConfig{Verbosity: 1}
+	defaults := updateConfig{Verbosity: 1, PinnedDigests: map[string]string{}, SignaturePolicy: string(codex.SignatureOff), SignatureKeys: []codex.SignatureKey{}, VersionsPath: "", KeepVersions: codex.DefaultKeepVersions, TargetConfig: cli.DefaultTargetConfig()}
 	var cfg updateConfig
 	if _, err := config.Load(command, defaults, &cfg); err != nil {
 		log.Errorf(logger.PrefixCLI, "Failed to load config: %v", err)
@@ -42,31 +57,133 @@ func Run(args []string) int {
 	global := cli.GlobalFlags{}
 	global.Register(fs, cfg.Verbosity)
 
-	options := cli.GlobalUsageOptions()
+	var force bool
+	fs.BoolVar(&force, "force", false, "Reinstall even when Codex is up to date.")
+	cfg.TargetConfig.Register(fs)
+
+	options := append(cli.GlobalUsageOptions(), cli.UsageOption{
+		Long:        "force",
+		Short:       "f",
+		Description: "Download and install the latest release even when the installed Codex is already current.",
+	})
+	options = append(options, cli.TargetUsageOptions()...)
+	commands := []cli.UsageCommand{
+		{Name: "list-installed", Description: "List the Codex versions kept in the version store."},
+		{Name: "use", Args: "<tag>", Description: "Switch to an installed version without downloading it."},
+		{Name: "rollback", Description: "Switch back to the version that was active before the current one."},
+	}
 	fs.Usage = func() {
-		cli.UsagePrinter{Command: command, Synopsis: synopsis, Options: options}.Print()
+		cli.UsagePrinter{Command: command, Synopsis: synopsis, Commands: commands, Options: options}.Print()
 	}
 
-	leftovers, err := cli.Parse(fs, args, []cli.FlagAlias{{Canonical: "verbosity", Short: "v", HasValue: true}})
+	positional, err := cli.ParseInterspersed(fs, args, append([]cli.FlagAlias{
+		{Canonical: "verbosity", Short: "v", HasValue: true},
+		{Canonical: "force", Short: "f"},
+	}, cli.TargetAliases()...))
 	if err != nil {
 		log.Errorf(logger.PrefixCLI, "Flag parsing failed: %v", err)
 		return 1
 	}
-	if len(leftovers) > 0 {
-		log.Errorf(logger.PrefixCLI, "Unexpected positional arguments: %v", leftovers)
-		return 1
-	}
 	if err := cli.ValidateVerbosity(global.Verbosity); err != nil {
 		log.Errorf(logger.PrefixCLI, "Invalid verbosity: %v", err)
 		return 1
 	}
 
+	policy, err := codex.ParseSignaturePolicy(cfg.SignaturePolicy)
+	if err != nil {
+		log.Errorf(logger.PrefixCLI, "Invalid config: %v", err)
+		return 1
+	}
+
+	target, escalation, err := cfg.TargetConfig.Resolve()
+	if err != nil {
+		log.Errorf(logger.PrefixCLI, "Invalid install target: %v", err)
+		return 1
+	}
+
+	versionsDir := cfg.VersionsPath
+	if versionsDir == "" {
+		if versionsDir, err = codex.DefaultStoreDir(); err != nil {
+			log.Errorf(logger.PrefixCLI, "Failed to resolve the version store: %v", err)
+			return 1
+		}
+	}
+
+	installer := codex.Installer{
+		Client:       codex.NewClient(nil, cfg.GitHubToken),
+		Log:          log,
+		TargetPath:   target,
+		Escalation:   escalation,
+		Store:        codex.Store{Dir: versionsDir},
+		KeepVersions: cfg.KeepVersions,
+		Force:        force,
+
+		PinnedDigests:   cfg.PinnedDigests,
+		RequireChecksum: cfg.RequireChecksum,
+		SignaturePolicy: policy,
+		SignatureKeys:   cfg.SignatureKeys,
+	}
+	printer := output.Printer{Verbosity: global.Verbosity}
+	envDump := map[string]string{
+		"versions": versionsDir,
+		"target":   installer.TargetPath,
+	}
+	emit := func(payload any) int {
+		if err := printer.Print(envDump, payload); err != nil {
+			log.Errorf(logger.PrefixCLI, "Failed to render output: %v", err)
+			return 1
+		}
+		return 0
+	}
+
+	if len(positional) > 0 {
+		switch positional[0] {
+		case "list-installed":
+			if len(positional) != 1 {
+				log.Errorf(logger.PrefixCLI, "Usage: codex-update list-installed")
+				return 1
+			}
+			versions, err := installer.Store.List()
+			if err != nil {
+				log.Errorf(logger.PrefixInstall, "Failed to list installed versions: %v", err)
+				return 1
+			}
+			return emit(versions)
+		case "use":
+			if len(positional) != 2 {
+				log.Errorf(logger.PrefixCLI, "Usage: codex-update use <tag>")
+				return 1
+			}
+			result, err := installer.Use(positional[1])
+			if err != nil {
+				log.Errorf(logger.PrefixInstall, "Switching versions failed: %v", err)
+				return 1
+			}
+			return emit(result)
+		case "rollback":
+			if len(positional) != 1 {
+				log.Errorf(logger.PrefixCLI, "Usage: codex-update rollback")
+				return 1
+			}
+			result, err := installer.Rollback()
+			if err != nil {
+				log.Errorf(logger.PrefixInstall, "Rollback failed: %v", err)
+				return 1
+			}
+			return emit(result)
+		default:
+			log.Errorf(logger.PrefixCLI, "Unknown command %q", positional[0])
+			return 1
+		}
+	}
+
 	workspace, err := env.PrepareWorkspace()
 	if err != nil {
 		log.Errorf(logger.PrefixCLI, "Failed to prepare workspace: %v", err)
 		return 1
 	}
 	defer env.CleanupWorkspace(workspace)
+	installer.Workdir = workspace
 
 	platform, err := codex.DetectPlatform()
 	if err != nil {
@@ -74,27 +191,13 @@ func Run(args []string) int {
 		return 1
 	}
 
-	installer := codex.Installer{
-		Client:     codex.NewClient(nil, cfg.GitHubToken),
-		Log:        log,
-		Workdir:    workspace,
-		TargetPath: env.TargetBinaryPath(),
-	}
 	result, err := installer.InstallLatest(ctx, platform)
 	if err != nil {
 		log.Errorf(logger.PrefixInstall, "Installation failed: %v", err)
 		return 1
 	}
-
-	printer := output.Printer{Verbosity: global.Verbosity}
-	envDump := map[string]string{
-		"workspace": workspace,
-		"target":    result.Target,
-		"archive":   result.Archive,
-	}
-	if err := printer.Print(envDump, result); err != nil {
-		log.Errorf(logger.PrefixCLI, "Failed to render output: %v", err)
-		return 1
-	}
-	return 0
+	envDump["workspace"] = workspace
+	envDump["archive"] = result.Archive
+	envDump["digest"] = result.Digest
+	return emit(result)
 }
@@ -24,6 +24,21 @@ type updateSelectConfig struct {
 	Verbosity    int    `yaml:"verbosity"`
 	ReleaseLimit int    `yaml:"release-limit"`
 	GitHubToken  string `yaml:"github-token"`
+	// PinnedDigests maps "<tag>/<archive>" to the expected SHA-256.
+	PinnedDigests map[string]string `yaml:"pinned-digests"`
+	// RequireChecksum refuses releases that publish no checksum; by default
+	// they are installed with a warning.
+	RequireChecksum bool `yaml:"require-checksum"`
+	// SignaturePolicy is off, warn or require; SignatureKeys are the keys
+	// trusted to sign releases.
+	SignaturePolicy string               `yaml:"signature-policy"`
+	SignatureKeys   []codex.SignatureKey `yaml:"signature-keys"`
+	// VersionsPath stores every installed release; empty means
+	// ~/.local/share/codex-control/versions.
+	VersionsPath string `yaml:"versions-path"`
+	KeepVersions int    `yaml:"keep-versions"`
+
+	cli.TargetConfig `yaml:",inline"`
 }
 
 // Run executes the codex-update-select workflow.
@@ -35,7 +50,7 @@ func Run(args []string) int {
 	const synopsis = "codex-update-select [options]"
 
 	log := logger.New()
-	defaults := updateSelectConfig{Verbosity: 1, ReleaseLimit: 200, GitHubToken: ""}
+	defaults := updateSelectConfig{Verbosity: 1, ReleaseLimit: 200, GitHubToken: "", PinnedDigests: map[string]string{}, SignaturePolicy: string(codex.SignatureOff), SignatureKeys: []codex.SignatureKey{}, VersionsPath: "", KeepVersions: codex.DefaultKeepVersions, TargetConfig: cli.DefaultTargetConfig()}
 	var settings updateSelectConfig
 	if _, err := config.Load(command, defaults, &settings); err != nil {
 		log.Errorf(logger.PrefixCLI, "Failed to load config: %v", err)
@@ -50,6 +65,7 @@ func Run(args []string) int {
 
 	var releaseLimit int
 	fs.IntVar(&releaseLimit, "release-limit", settings.ReleaseLimit, "Maximum number of releases to display.")
+	settings.TargetConfig.Register(fs)
 
 	options := append(cli.GlobalUsageOptions(), cli.UsageOption{
 		Long:        "release-limit",
@@ -57,14 +73,15 @@ func Run(args []string) int {
 		Value:       "<count>",
 		Description: "Limit the number of releases fetched from GitHub.",
 	})
+	options = append(options, cli.TargetUsageOptions()...)
 	fs.Usage = func() {
 		cli.UsagePrinter{Command: command, Synopsis: synopsis, Options: options}.Print()
 	}
 
-	leftovers, err := cli.Parse(fs, args, []cli.FlagAlias{
+	leftovers, err := cli.Parse(fs, args, append([]cli.FlagAlias{
 		{Canonical: "verbosity", Short: "v", HasValue: true},
 		{Canonical: "release-limit", Short: "l", HasValue: true},
-	})
+	}, cli.TargetAliases()...))
 	if err != nil {
 		log.Errorf(logger.PrefixCLI, "Flag parsing failed: %v", err)
 		return 1
@@ -81,6 +98,26 @@ func Run(args []string) int {
 		releaseLimit = defaults.ReleaseLimit
 	}
 
+	policy, err := codex.ParseSignaturePolicy(settings.SignaturePolicy)
+	if err != nil {
+		log.Errorf(logger.PrefixCLI, "Invalid config: %v", err)
+		return 1
+	}
+
+	target, escalation, err := settings.TargetConfig.Resolve()
+	if err != nil {
+		log.Errorf(logger.PrefixCLI, "Invalid install target: %v", err)
+		return 1
+	}
+
+	versionsDir := settings.VersionsPath
+	if versionsDir == "" {
+		if versionsDir, err = codex.DefaultStoreDir(); err != nil {
+			log.Errorf(logger.PrefixCLI, "Failed to resolve the version store: %v", err)
+			return 1
+		}
+	}
+
 	workspace, err := env.PrepareWorkspace()
 	if err != nil {
 		log.Errorf(logger.PrefixCLI, "Failed to prepare workspace: %v", err)
@@ -95,8 +132,20 @@ func Run(args []string) int {
 	}
 
 	client := codex.NewClient(nil, settings.GitHubToken)
-	installer := codex.Installer{Client: client, Log: log, Workdir: workspace, TargetPath: env.TargetBinaryPath()}
-	loader := &releaseLoader{client: client, platform: platform, limit: releaseLimit}
+	installer := codex.Installer{
+		Client:          client,
+		Log:             log,
+		Workdir:         workspace,
+		TargetPath:      target,
+		Escalation:      escalation,
+		Store:           codex.Store{Dir: versionsDir},
+		KeepVersions:    settings.KeepVersions,
+		PinnedDigests:   settings.PinnedDigests,
+		RequireChecksum: settings.RequireChecksum,
+		SignaturePolicy: policy,
+		SignatureKeys:   settings.SignatureKeys,
+	}
+	loader := &releaseLoader{client: client, platform: platform, limit: releaseLimit, store: installer.Store}
 
 	cfg := menu.Config{
 		Context:          ctx,
@@ -143,6 +192,7 @@ func Run(args []string) int {
 		"workspace": workspace,
 		"target":    installResult.Target,
 		"archive":   installResult.Archive,
+		"digest":    installResult.Digest,
 	}
 	if err := printer.Print(envDump, installResult); err != nil {
 		log.Errorf(logger.PrefixCLI, "Failed to render output: %v", err)
@@ -160,6 +210,7 @@ type releaseLoader struct {
 	client   *codex.Client
 	platform codex.Platform
 	limit    int
+	store    codex.Store
 }
 
 func (r *releaseLoader) Load(ctx context.Context) ([]menu.Entry, error) {
@@ -174,10 +225,17 @@ func (r *releaseLoader) Load(ctx context.Context) ([]menu.Entry, error) {
 		if !ok {
 			continue
 		}
+		badges := []string{"ready"}
+		if version, installed, _ := r.store.Get(rel.Tag); installed {
+			badges = []string{"installed"}
+			if version.Active {
+				badges = []string{"active"}
+			}
+		}
 		entries = append(entries, menu.Entry{
 			Title:       rel.Tag,
 			Description: fmt.Sprintf("%s • %s", humanSize(asset.Size), formatPublished(rel.PublishedAt)),
-			Badges:      []string{"ready"},
+			Badges:      badges,
 			Payload:     releaseChoice{Release: rel, Asset: asset},
 		})
 	}
@@ -206,11 +264,18 @@ func runInstallCmd(ctx context.Context, installer *codex.Installer, choice relea
 	return func() tea.Msg {
 		installCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
 		defer cancel()
-		result, err := installer.InstallRelease(installCtx, choice.Release, choice.Asset)
+		// Versions already in the store are switched to without downloading.
+		install := func() (codex.InstallResult, error) {
+			return installer.InstallRelease(installCtx, choice.Release, choice.Asset)
+		}
+		if _, stored, _ := installer.Store.Get(choice.Release.Tag); stored {
+			install = func() (codex.InstallResult, error) { return installer.Use(choice.Release.Tag) }
+		}
+		result, err := install()
 		if err != nil {
 			return menu.PanelUpdate("Install release", err.Error(), result, err)
 		}
-		content := fmt.Sprintf("Version %s installed at %s", result.Version, result.Target)
+		content := fmt.Sprintf("Version %s installed at %s (%s)", result.Version, result.Target, result.Digest)
 		return menu.PanelUpdate("Install release", content, result, nil)
 	}
 }
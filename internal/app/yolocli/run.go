@@ -5,18 +5,31 @@ import (
 	"flag"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 
+	"codex-control/internal/auth"
 	"codex-control/internal/cli"
 	"codex-control/internal/config"
 	"codex-control/internal/logger"
 	"codex-control/internal/output"
+	"codex-control/internal/profiles"
 	"codex-control/internal/yolo"
 )
 
 type yoloConfig struct {
 	Verbosity   int    `yaml:"verbosity"`
 	CodexBinary string `yaml:"codex-binary"`
+	AutoProfile bool   `yaml:"auto-profile"`
+	// CodexHome runs Codex against this home instead of $CODEX_HOME.
+	CodexHome string `yaml:"codex-home"`
+	// Isolate runs Codex in the chosen profile's own Codex home.
+	Isolate bool `yaml:"isolate"`
+	// RotateOnLimit switches profiles when Codex reports a usage limit.
+	RotateOnLimit  bool     `yaml:"rotate-on-limit"`
+	RotationPool   []string `yaml:"rotation-pool"`
+	MaxRotations   int      `yaml:"max-rotations"`
+	LimitExitCodes []int    `yaml:"limit-exit-codes"`
 }
 
 // Run executes the codex-yolo style CLI for the provided mode.
@@ -26,7 +39,13 @@ func Run(mode yolo.Mode, command string, synopsis string) int {
 
 	log := logger.New()
 
-	defaults := yoloConfig{Verbosity: 1, CodexBinary: "codex"}
+	defaults := yoloConfig{
+		Verbosity:      1,
+		CodexBinary:    "codex",
+		RotationPool:   []string{},
+		MaxRotations:   3,
+		LimitExitCodes: []int{},
+	}
 	var cfg yoloConfig
 	if _, err := config.Load(command, defaults, &cfg); err != nil {
 		log.Errorf(logger.PrefixCLI, "Failed to load config: %v", err)
@@ -40,13 +59,55 @@ func Run(mode yolo.Mode, command string, synopsis string) int {
 
 	var codexBinary string
 	fs.StringVar(&codexBinary, "codex-binary", cfg.CodexBinary, "Path to the codex binary.")
+	var autoProfile bool
+	fs.BoolVar(&autoProfile, "auto-profile", cfg.AutoProfile, "Install the .codex-profile bound profile before launching.")
+	var codexHome string
+	fs.StringVar(&codexHome, "codex-home", cfg.CodexHome, "Codex home directory to run against.")
+	var isolate bool
+	fs.BoolVar(&isolate, "isolate", cfg.Isolate, "Run Codex in the profile's own Codex home.")
+	var profile string
+	fs.StringVar(&profile, "profile", "", "Profile to run Codex with in isolation mode.")
+	var rotate bool
+	fs.BoolVar(&rotate, "rotate", cfg.RotateOnLimit, "Switch profiles and resume when Codex hits a usage limit.")
+	var maxRotations int
+	fs.IntVar(&maxRotations, "max-rotations", cfg.MaxRotations, "Maximum profile switches per run.")
 
-	options := append(cli.GlobalUsageOptions(), cli.UsageOption{
-		Long:        "codex-binary",
-		Short:       "c",
-		Value:       "<path>",
-		Description: "Override the codex executable path.",
-	})
+	options := append(cli.GlobalUsageOptions(),
+		cli.UsageOption{
+			Long:        "codex-binary",
+			Short:       "c",
+			Value:       "<path>",
+			Description: "Override the codex executable path.",
+		},
+		cli.UsageOption{
+			Long:        "auto-profile",
+			Description: "Install the profile named by the nearest .codex-profile before launching Codex.",
+		},
+		cli.UsageOption{
+			Long:        "codex-home",
+			Value:       "<path>",
+			Description: "Run Codex against this home (exported as CODEX_HOME).",
+		},
+		cli.UsageOption{
+			Long:        "isolate",
+			Description: "Run Codex in the isolated home of --profile (or the .codex-profile binding).",
+		},
+		cli.UsageOption{
+			Long:        "profile",
+			Short:       "p",
+			Value:       "<name>",
+			Description: "Profile used with --isolate.",
+		},
+		cli.UsageOption{
+			Long:        "rotate",
+			Description: "On a usage limit, install the next profile from rotation-pool and resume the session.",
+		},
+		cli.UsageOption{
+			Long:        "max-rotations",
+			Value:       "<count>",
+			Description: "Cap the number of profile switches per run.",
+		},
+	)
 	fs.Usage = func() {
 		cli.UsagePrinter{Command: command, Synopsis: synopsis, Options: options}.Print()
 	}
@@ -54,6 +115,7 @@ func Run(mode yolo.Mode, command string, synopsis string) int {
 	args, err := cli.Parse(fs, os.Args[1:], []cli.FlagAlias{
 		{Canonical: "verbosity", Short: "v", HasValue: true},
 		{Canonical: "codex-binary", Short: "c", HasValue: true},
+		{Canonical: "profile", Short: "p", HasValue: true},
 	})
 	if err != nil {
 		log.Errorf(logger.PrefixCLI, "Flag parsing failed: %v", err)
@@ -67,7 +129,58 @@ func Run(mode yolo.Mode, command string, synopsis string) int {
 		codexBinary = defaults.CodexBinary
 	}
 
-	runner := yolo.Runner{Binary: codexBinary, Mode: mode, Log: log}
+	if err := auth.SetCodexHome(codexHome); err != nil {
+		log.Errorf(logger.PrefixCLI, "Invalid Codex home: %v", err)
+		return 1
+	}
+	if profile != "" && !isolate {
+		log.Errorf(logger.PrefixCLI, "--profile requires --isolate")
+		return 1
+	}
+
+	if isolate {
+		name, home, err := profiles.PrepareIsolatedHome(log, profile)
+		if err != nil {
+			log.Errorf(logger.PrefixAuth, "Failed to prepare isolated Codex home: %v", err)
+			return 1
+		}
+		log.Printf(logger.PrefixAuth, "Running %s in %s", name, home)
+	} else if autoProfile {
+		profile, installed, err := profiles.InstallProjectProfile(log)
+		if err != nil {
+			log.Errorf(logger.PrefixAuth, "Failed to install project profile: %v", err)
+			return 1
+		}
+		if installed {
+			log.Printf(logger.PrefixAuth, "Installed project profile %s", profile)
+		}
+	}
+
+	runner := yolo.Runner{Binary: codexBinary, Mode: mode, Log: log, Environment: profiles.Environment}
+	if rotate {
+		rotator, err := profiles.NewRotator(log, cfg.RotationPool)
+		if err != nil {
+			log.Errorf(logger.PrefixAuth, "Failed to prepare profile rotation: %v", err)
+			return 1
+		}
+		codexDir, err := auth.CodexDir()
+		if err != nil {
+			log.Errorf(logger.PrefixCLI, "Failed to resolve the Codex directory: %v", err)
+			return 1
+		}
+		runner.Rotate = rotator.Next
+		runner.MaxRotations = maxRotations
+		runner.SessionsDir = filepath.Join(codexDir, "sessions")
+		runner.LimitExitCodes = cfg.LimitExitCodes
+	}
+	if _, managed, _ := auth.LoadInstallState(); managed {
+		release, err := profiles.HoldLease(ctx, log)
+		if err != nil {
+			log.Errorf(logger.PrefixAuth, "Failed to lease the installed profile: %v", err)
+		} else {
+			defer release()
+		}
+	}
 	result, runErr := runner.Run(ctx, args)
 	if runErr != nil {
 		log.Errorf(logger.PrefixCodex, "Codex failed: %v", runErr)
@@ -75,13 +188,15 @@ func Run(mode yolo.Mode, command string, synopsis string) int {
 	}
 	printer := output.Printer{Verbosity: global.Verbosity}
 	env := map[string]string{
-		"binary": codexBinary,
-		"mode":   string(mode),
+		"binary":     codexBinary,
+		"mode":       string(mode),
+		"codex_home": os.Getenv(auth.CodexHomeEnv),
 	}
 	payload := struct {
-		Command  []string `json:"command"`
-		ExitCode int      `json:"exit_code"`
-	}{Command: result.Command, ExitCode: result.ExitCode}
+		Command   []string `json:"command"`
+		ExitCode  int      `json:"exit_code"`
+		Rotations []string `json:"rotations,omitempty"`
+	}{Command: result.Command, ExitCode: result.ExitCode, Rotations: result.Rotations}
 	if err := printer.Print(env, payload); err != nil {
 		log.Errorf(logger.PrefixCLI, "Failed to render output: %v", err)
 	}
@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"errors"
+	"os"
+)
+
+// ActiveMatch names the stored profile that the live auth.json belongs to and
+// how it was recognised.
+type ActiveMatch struct {
+	File   File   `json:"-"`
+	Reason string `json:"reason"`
+}
+
+// FindActive compares the live auth.json with files and returns the profile it
+// was installed from. The last installed profile wins while the live file
+// still holds its content or account; otherwise an identical file is
+// accepted, and finally a single profile with the same account and email.
+// Account matches keep the result stable after Codex refreshes the tokens in
+// auth.json. The boolean is false when the live credentials are unknown or
+// not saved.
+func FindActive(files []File) (ActiveMatch, bool, error) {
+	live, err := LiveAuthPath()
+	if err != nil {
+		return ActiveMatch{}, false, err
+	}
+	content, err := os.ReadFile(live)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return ActiveMatch{}, false, nil
+		}
+		return ActiveMatch{}, false, err
+	}
+	liveDigest := digest(content)
+	liveID, _ := ParseIdentity(content)
+	state, ok, err := LoadInstallState()
+	if err != nil {
+		return ActiveMatch{}, false, err
+	}
+	if ok {
+		for _, file := range files {
+			if file.Path != state.Source {
+				continue
+			}
+			if state.Digest == liveDigest || sameAccount(liveID, Identity{AccountID: state.AccountID, Email: state.Email}) || sameAccount(liveID, file.Identity) {
+				return ActiveMatch{File: file, Reason: "installed"}, true, nil
+			}
+		}
+	}
+	for _, file := range files {
+		if file.Encrypted {
+			continue
+		}
+		raw, err := os.ReadFile(file.Path)
+		if err == nil && digest(raw) == liveDigest {
+			return ActiveMatch{File: file, Reason: "identical"}, true, nil
+		}
+	}
+
+	var candidates []File
+	for _, file := range files {
+		if sameAccount(liveID, file.Identity) {
+			candidates = append(candidates, file)
+		}
+	}
+	if len(candidates) == 1 {
+		return ActiveMatch{File: candidates[0], Reason: "account"}, true, nil
+	}
+	return ActiveMatch{}, false, nil
+}
+
+// sameAccount reports whether two identities name the same account. API-key
+// profiles carry no account and never match.
+func sameAccount(a, b Identity) bool {
+	if a.AccountID == "" || a.AccountID != b.AccountID {
+		return false
+	}
+	return a.Email == "" || b.Email == "" || a.Email == b.Email
+}
@@ -0,0 +1,242 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	apiProfileVersion = 1
+	defaultKeyEnv     = "OPENAI_API_KEY"
+)
+
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// APIProfile is a profile that describes an API key and provider settings
+// instead of holding Codex login tokens. Exactly one of APIKey, APIKeyFile and
+// APIKeyCommand supplies the key.
+type APIProfile struct {
+	Version       int    `json:"codex_auth_api_profile"`
+	APIKey        string `json:"api_key,omitempty"`
+	APIKeyFile    string `json:"api_key_file,omitempty"`
+	APIKeyCommand string `json:"api_key_command,omitempty"`
+	// EnvKey is the variable the key is exported as, OPENAI_API_KEY by default.
+	EnvKey string `json:"env_key,omitempty"`
+	// BaseURL points the OpenAI provider, or Provider when set, at another
+	// endpoint.
+	BaseURL  string            `json:"base_url,omitempty"`
+	Provider *Provider         `json:"provider,omitempty"`
+	Env      map[string]string `json:"env,omitempty"`
+}
+
+// Provider selects a Codex model provider other than the built-in OpenAI one,
+// such as Azure or an OpenAI-compatible endpoint.
+type Provider struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name,omitempty"`
+	WireAPI     string            `json:"wire_api,omitempty"`
+	QueryParams map[string]string `json:"query_params,omitempty"`
+}
+
+// IsAPIProfile reports whether raw is an API key profile rather than a Codex
+// auth.json.
+func IsAPIProfile(raw []byte) bool {
+	var probe struct {
+		Version int `json:"codex_auth_api_profile"`
+	}
+	if err := json.Unmarshal(raw, &probe); err != nil {
+		return false
+	}
+	return probe.Version > 0
+}
+
+// ParseAPIProfile decodes and validates an API key profile.
+func ParseAPIProfile(raw []byte) (APIProfile, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	var profile APIProfile
+	if err := decoder.Decode(&profile); err != nil {
+		return APIProfile{}, fmt.Errorf("invalid API key profile: %w", err)
+	}
+	if profile.Version != apiProfileVersion {
+		return APIProfile{}, fmt.Errorf("unsupported API key profile version %d", profile.Version)
+	}
+	sources := 0
+	for _, source := range []string{profile.APIKey, profile.APIKeyFile, profile.APIKeyCommand} {
+		if strings.TrimSpace(source) != "" {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return APIProfile{}, errors.New("API key profile needs exactly one of api_key, api_key_file and api_key_command")
+	}
+	if profile.EnvKey != "" && !envNamePattern.MatchString(profile.EnvKey) {
+		return APIProfile{}, fmt.Errorf("invalid env_key %q", profile.EnvKey)
+	}
+	for name := range profile.Env {
+		if !envNamePattern.MatchString(name) {
+			return APIProfile{}, fmt.Errorf("invalid environment variable name %q", name)
+		}
+	}
+	if profile.Provider != nil && !envNamePattern.MatchString(profile.Provider.ID) {
+		return APIProfile{}, fmt.Errorf("invalid provider id %q", profile.Provider.ID)
+	}
+	return profile, nil
+}
+
+// keyEnv returns the variable the key is exported as.
+func (p APIProfile) keyEnv() string {
+	if p.EnvKey != "" {
+		return p.EnvKey
+	}
+	return defaultKeyEnv
+}
+
+// ResolveKey reads the API key from the profile, its key file or the output
+// of its key command, run through sh.
+func (p APIProfile) ResolveKey() (string, error) {
+	var key string
+	switch {
+	case p.APIKey != "":
+		key = p.APIKey
+	case p.APIKeyFile != "":
+		path, err := expandHome(p.APIKeyFile)
+		if err != nil {
+			return "", err
+		}
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read API key file: %w", err)
+		}
+		key = string(raw)
+	default:
+		cmd := exec.Command("sh", "-c", p.APIKeyCommand)
+		cmd.Stdin = os.Stdin
+		cmd.Stderr = os.Stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("API key command failed: %w", err)
+		}
+		key = string(out)
+	}
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return "", errors.New("API key is empty")
+	}
+	return key, nil
+}
+
+// AuthJSON renders the auth.json Codex expects for key. Keys meant for another
+// provider's variable are left out so they never reach the OpenAI API.
+func (p APIProfile) AuthJSON(key string) ([]byte, error) {
+	doc := map[string]any{defaultKeyEnv: nil, "tokens": nil, "last_refresh": nil}
+	if p.keyEnv() == defaultKeyEnv {
+		doc[defaultKeyEnv] = key
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// Environ returns the NAME=value pairs Codex needs to use key: the key
+// variable, OPENAI_BASE_URL for the OpenAI provider and the extra variables.
+func (p APIProfile) Environ(key string) []string {
+	env := []string{p.keyEnv() + "=" + key}
+	if p.BaseURL != "" && p.Provider == nil {
+		env = append(env, "OPENAI_BASE_URL="+p.BaseURL)
+	}
+	names := make([]string, 0, len(p.Env))
+	for name := range p.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		env = append(env, name+"="+p.Env[name])
+	}
+	return env
+}
+
+// ConfigOverrides returns the codex -c arguments that select the custom
+// provider, so it does not have to be declared in config.toml.
+func (p APIProfile) ConfigOverrides() []string {
+	if p.Provider == nil {
+		return nil
+	}
+	prefix := "model_providers." + p.Provider.ID + "."
+	name := p.Provider.Name
+	if name == "" {
+		name = p.Provider.ID
+	}
+	args := []string{
+		"-c", "model_provider=" + strconv.Quote(p.Provider.ID),
+		"-c", prefix + "name=" + strconv.Quote(name),
+		"-c", prefix + "env_key=" + strconv.Quote(p.keyEnv()),
+	}
+	if p.BaseURL != "" {
+		args = append(args, "-c", prefix+"base_url="+strconv.Quote(p.BaseURL))
+	}
+	if p.Provider.WireAPI != "" {
+		args = append(args, "-c", prefix+"wire_api="+strconv.Quote(p.Provider.WireAPI))
+	}
+	if len(p.Provider.QueryParams) > 0 {
+		keys := make([]string, 0, len(p.Provider.QueryParams))
+		for key := range p.Provider.QueryParams {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		pairs := make([]string, len(keys))
+		for i, key := range keys {
+			pairs[i] = strconv.Quote(key) + " = " + strconv.Quote(p.Provider.QueryParams[key])
+		}
+		args = append(args, "-c", prefix+"query_params={ "+strings.Join(pairs, ", ")+" }")
+	}
+	return args
+}
+
+// materialize turns the plaintext of a profile into the auth.json to install,
+// resolving the key of API key profiles.
+func materialize(plain []byte) ([]byte, error) {
+	if !IsAPIProfile(plain) {
+		return plain, nil
+	}
+	profile, err := ParseAPIProfile(plain)
+	if err != nil {
+		return nil, err
+	}
+	key, err := profile.ResolveKey()
+	if err != nil {
+		return nil, err
+	}
+	return profile.AuthJSON(key)
+}
+
+// LoadAPIProfile reads the API key profile at path, decrypting it when needed. The boolean is false for ordinary Codex auth files.
+func LoadAPIProfile(path string, passphrase PassphraseFunc) (APIProfile, bool, error) {
+	plain, _, err := readProfile(path, passphrase)
+	if err != nil {
+		return APIProfile{}, false, err
+	}
+	if !IsAPIProfile(plain) {
+		return APIProfile{}, false, nil
+	}
+	profile, err := ParseAPIProfile(plain)
+	return profile, err == nil, err
+}
+
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~")), nil
+}
@@ -0,0 +1,196 @@
+package auth
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"codex-control/internal/fsx"
+)
+
+const (
+	backupDirName      = ".codex-auth-backups"
+	backupPrefix       = "auth-"
+	backupSuffix       = ".json"
+	backupStampLayout  = "20060102T150405.000Z"
+	DefaultBackupLimit = 10
+)
+
+// Backup describes a saved copy of a previously live auth.json.
+type Backup struct {
+	Name      string    `json:"name"`
+	Path      string    `json:"path"`
+	CreatedAt time.Time `json:"created_at"`
+	Size      int64     `json:"size"`
+	Identity  Identity  `json:"-"`
+}
+
+// InstallOptions tunes how profiles are installed, saved and synced.
+type InstallOptions struct {
+	// BackupLimit caps the number of rotated backups. Zero uses
+	// DefaultBackupLimit, a negative value disables backups.
+	BackupLimit int
+	// Passphrase unlocks encrypted profiles when they are read or rewritten.
+	Passphrase PassphraseFunc
+}
+
+func (o InstallOptions) backupLimit() int {
+	if o.BackupLimit == 0 {
+		return DefaultBackupLimit
+	}
+	return o.BackupLimit
+}
+
+// ListBackups returns the stored backups, newest first.
+func ListBackups() ([]Backup, error) {
+	dir, err := backupDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	backups := make([]Backup, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, backupPrefix) || !strings.HasSuffix(name, backupSuffix) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, backupPrefix), backupSuffix)
+		created, err := time.Parse(backupStampLayout, stamp)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		path := filepath.Join(dir, name)
+		identity, _ := ReadIdentity(path)
+		backups = append(backups, Backup{Name: name, Path: path, CreatedAt: created, Size: info.Size(), Identity: identity})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+	return backups, nil
+}
+
+// FindBackup resolves a backup by exact name or unique prefix.
+func FindBackup(backups []Backup, query string) (Backup, error) {
+	var matches []Backup
+	for _, backup := range backups {
+		if backup.Name == query {
+			return backup, nil
+		}
+		if strings.HasPrefix(backup.Name, query) || strings.HasPrefix(strings.TrimPrefix(backup.Name, backupPrefix), query) {
+			matches = append(matches, backup)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return Backup{}, fmt.Errorf("%w %q", ErrNoMatch, query)
+	case 1:
+		return matches[0], nil
+	default:
+		return Backup{}, fmt.Errorf("backup %q is ambiguous (%d matches)", query, len(matches))
+	}
+}
+
+// RestoreBackup copies a backup into the live auth.json. The replaced file is
+// backed up in turn, so a restore can itself be undone. The installed profile
+// record is cleared because the restored credentials may not match any profile.
+func RestoreBackup(backup Backup, opts InstallOptions) (CopyResult, error) {
+	dest, err := LiveAuthPath()
+	if err != nil {
+		return CopyResult{}, err
+	}
+	unlock, err := lockLive()
+	if err != nil {
+		return CopyResult{}, err
+	}
+	defer unlock()
+	content, err := os.ReadFile(backup.Path)
+	if err != nil {
+		return CopyResult{}, err
+	}
+	saved, err := backupLive(content, opts.backupLimit())
+	if err != nil {
+		return CopyResult{}, err
+	}
+	if err := fsx.WriteFile(dest, content, 0o600); err != nil {
+		return CopyResult{}, err
+	}
+	if err := clearInstallState(); err != nil {
+		return CopyResult{}, err
+	}
+	return CopyResult{Source: backup.Path, Destination: dest, Bytes: int64(len(content)), Backup: saved}, nil
+}
+
+// backupLive stores the current live auth.json before it is replaced by next.
+// Nothing is written when the live file is missing, already equals next or
+// matches the newest backup. The returned path is empty when no backup exists.
+func backupLive(next []byte, limit int) (string, error) {
+	if limit < 0 {
+		return "", nil
+	}
+	live, err := LiveAuthPath()
+	if err != nil {
+		return "", err
+	}
+	current, err := os.ReadFile(live)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+		return "", err
+	}
+	if bytes.Equal(current, next) {
+		return "", nil
+	}
+	backups, err := ListBackups()
+	if err != nil {
+		return "", err
+	}
+	if len(backups) > 0 {
+		newest, err := os.ReadFile(backups[0].Path)
+		if err == nil && bytes.Equal(newest, current) {
+			return backups[0].Path, nil
+		}
+	}
+	dir, err := backupDir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	name := backupPrefix + time.Now().UTC().Format(backupStampLayout) + backupSuffix
+	path := filepath.Join(dir, name)
+	if err := fsx.WriteFile(path, current, 0o600); err != nil {
+		return "", err
+	}
+	backups = append([]Backup{{Name: name, Path: path}}, backups...)
+	for _, stale := range backups[min(limit, len(backups)):] {
+		if err := os.Remove(stale.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return path, err
+		}
+	}
+	return path, nil
+}
+
+func backupDir() (string, error) {
+	live, err := LiveAuthPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(live), backupDirName), nil
+}
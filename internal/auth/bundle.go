@@ -0,0 +1,376 @@
+package auth
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"codex-control/internal/fsx"
+)
+
+const (
+	bundleVersion      = 1
+	bundleManifestName = "manifest.json"
+	bundleUsageName    = "usage.json"
+	bundleHistoryName  = "history.jsonl"
+	bundleProfilesDir  = "profiles"
+)
+
+// MaxBundleSize caps both the archive read from disk and the data it unpacks
+// to, so a corrupt or hostile archive cannot exhaust memory.
+const MaxBundleSize = 64 << 20
+
+var errBundleTooLarge = fmt.Errorf("bundle is larger than %d MiB", MaxBundleSize>>20)
+
+// ConflictPolicy decides what an import does with profiles that already exist.
+type ConflictPolicy string
+
+const (
+	ConflictSkip      ConflictPolicy = "skip"
+	ConflictRename    ConflictPolicy = "rename"
+	ConflictOverwrite ConflictPolicy = "overwrite"
+)
+
+// ParseConflictPolicy validates a --on-conflict value.
+func ParseConflictPolicy(value string) (ConflictPolicy, error) {
+	switch policy := ConflictPolicy(value); policy {
+	case ConflictSkip, ConflictRename, ConflictOverwrite:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown conflict policy %q (want skip, rename or overwrite)", value)
+}
+
+// BundleManifest describes an exported bundle.
+type BundleManifest struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	Host      string    `json:"host,omitempty"`
+	Profiles  []string  `json:"profiles"`
+}
+
+// BundleProfile is one profile inside a bundle, stored exactly as on disk.
+// Name is its path relative to the auths root, including any group folder.
+type BundleProfile struct {
+	Name string
+	Data []byte
+	Meta []byte
+}
+
+// Bundle carries profiles, their metadata and usage data between machines.
+type Bundle struct {
+	Manifest BundleManifest
+	Profiles []BundleProfile
+	Usage    map[string]time.Time
+	History  []HistoryEntry
+}
+
+// ImportResult reports what happened to one bundled profile.
+type ImportResult struct {
+	Profile    string `json:"profile"`
+	ImportedAs string `json:"imported_as,omitempty"`
+	Status     string `json:"status"`
+}
+
+// ExportBundle collects files together with their metadata sidecars, last-used
+// timestamps and switch history from tracker.
+func ExportBundle(files []File, tracker *UsageTracker) (Bundle, error) {
+	bundle := Bundle{
+		Manifest: BundleManifest{Version: bundleVersion, CreatedAt: time.Now().UTC(), Profiles: []string{}},
+		Usage:    map[string]time.Time{},
+	}
+	bundle.Manifest.Host, _ = os.Hostname()
+	included := map[string]string{}
+	for _, file := range files {
+		data, err := os.ReadFile(file.Path)
+		if err != nil {
+			return Bundle{}, err
+		}
+		meta, err := os.ReadFile(MetadataPath(file.Path))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return Bundle{}, err
+		}
+		name := file.RelPath()
+		bundle.Profiles = append(bundle.Profiles, BundleProfile{Name: name, Data: data, Meta: meta})
+		bundle.Manifest.Profiles = append(bundle.Manifest.Profiles, name)
+		included[tracker.Key(file)] = name
+		if used := tracker.LastUsed(tracker.Key(file)); !used.IsZero() {
+			bundle.Usage[name] = used
+		}
+	}
+	history, err := tracker.History()
+	if err != nil {
+		return Bundle{}, err
+	}
+	for _, entry := range history {
+		if name, ok := included[entry.Profile]; ok {
+			entry.Profile = name
+			bundle.History = append(bundle.History, entry)
+		}
+	}
+	return bundle, nil
+}
+
+// Marshal packs the bundle as a gzip-compressed tar archive.
+func (b Bundle) Marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	add := func(name string, data []byte) error {
+		header := &tar.Header{Name: name, Mode: 0o600, Size: int64(len(data)), ModTime: b.Manifest.CreatedAt}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+	manifest, err := json.MarshalIndent(b.Manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := add(bundleManifestName, manifest); err != nil {
+		return nil, err
+	}
+	for _, profile := range b.Profiles {
+		if err := add(path.Join(bundleProfilesDir, profile.Name), profile.Data); err != nil {
+			return nil, err
+		}
+		if profile.Meta != nil {
+			if err := add(path.Join(bundleProfilesDir, bundleMetaName(profile.Name)), profile.Meta); err != nil {
+				return nil, err
+			}
+		}
+	}
+	usage := make(map[string]string, len(b.Usage))
+	for name, ts := range b.Usage {
+		usage[name] = ts.UTC().Format(time.RFC3339)
+	}
+	raw, err := json.Marshal(usage)
+	if err != nil {
+		return nil, err
+	}
+	if err := add(bundleUsageName, raw); err != nil {
+		return nil, err
+	}
+	var history bytes.Buffer
+	encoder := json.NewEncoder(&history)
+	for _, entry := range b.History {
+		if err := encoder.Encode(entry); err != nil {
+			return nil, err
+		}
+	}
+	if err := add(bundleHistoryName, history.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ReadBundleFile reads the archive at path, refusing files larger than
+// MaxBundleSize.
+func ReadBundleFile(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	raw, err := io.ReadAll(io.LimitReader(file, MaxBundleSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) > MaxBundleSize {
+		return nil, errBundleTooLarge
+	}
+	return raw, nil
+}
+
+// ParseBundle unpacks an archive produced by Marshal. The unpacked entries
+// may add up to at most MaxBundleSize.
+func ParseBundle(raw []byte) (Bundle, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return Bundle{}, fmt.Errorf("not a codex-auth bundle: %w", err)
+	}
+	tr := tar.NewReader(gz)
+	entries := map[string][]byte{}
+	var total int64
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return Bundle{}, err
+		}
+		data, err := io.ReadAll(io.LimitReader(tr, MaxBundleSize-total+1))
+		if err != nil {
+			return Bundle{}, err
+		}
+		if total += int64(len(data)); total > MaxBundleSize {
+			return Bundle{}, errBundleTooLarge
+		}
+		entries[header.Name] = data
+	}
+	var bundle Bundle
+	manifest, ok := entries[bundleManifestName]
+	if !ok {
+		return Bundle{}, errors.New("bundle has no manifest")
+	}
+	if err := json.Unmarshal(manifest, &bundle.Manifest); err != nil {
+		return Bundle{}, err
+	}
+	if bundle.Manifest.Version != bundleVersion {
+		return Bundle{}, fmt.Errorf("unsupported bundle version %d", bundle.Manifest.Version)
+	}
+	for _, name := range bundle.Manifest.Profiles {
+		if !validBundleName(name) {
+			return Bundle{}, fmt.Errorf("bundle contains invalid profile name %q", name)
+		}
+		data, ok := entries[path.Join(bundleProfilesDir, name)]
+		if !ok {
+			return Bundle{}, fmt.Errorf("bundle is missing profile %s", name)
+		}
+		meta := entries[path.Join(bundleProfilesDir, bundleMetaName(name))]
+		bundle.Profiles = append(bundle.Profiles, BundleProfile{Name: name, Data: data, Meta: meta})
+	}
+	bundle.Usage = map[string]time.Time{}
+	if raw, ok := entries[bundleUsageName]; ok {
+		var usage map[string]string
+		if err := json.Unmarshal(raw, &usage); err != nil {
+			return Bundle{}, err
+		}
+		for name, value := range usage {
+			if ts, err := time.Parse(time.RFC3339, value); err == nil {
+				bundle.Usage[name] = ts
+			}
+		}
+	}
+	for _, line := range bytes.Split(entries[bundleHistoryName], []byte("\n")) {
+		var entry HistoryEntry
+		if err := json.Unmarshal(line, &entry); err == nil && entry.Profile != "" {
+			bundle.History = append(bundle.History, entry)
+		}
+	}
+	return bundle, nil
+}
+
+// ImportBundle writes the bundled profiles into root, resolving name clashes
+// with policy, and merges their usage data and history into tracker under
+// the names they were imported as. Overwritten profiles keep their local
+// metadata when the bundle has none, and stop counting as installed since
+// auth.json no longer mirrors them. A new profile that would share its
+// sidecar files with a local one stops the import.
+func ImportBundle(root string, bundle Bundle, policy ConflictPolicy, tracker *UsageTracker) ([]ImportResult, error) {
+	results := make([]ImportResult, 0, len(bundle.Profiles))
+	renamed := map[string]string{}
+	for _, profile := range bundle.Profiles {
+		result := ImportResult{Profile: profile.Name, ImportedAs: profile.Name, Status: "imported"}
+		dest := filepath.Join(root, filepath.FromSlash(profile.Name))
+		if err := os.MkdirAll(filepath.Dir(dest), 0o700); err != nil {
+			return results, err
+		}
+		if _, err := os.Lstat(dest); err == nil {
+			switch policy {
+			case ConflictOverwrite:
+				result.Status = "overwritten"
+			case ConflictRename:
+				dest = freeProfilePath(dest)
+				result.ImportedAs = path.Join(path.Dir(profile.Name), filepath.Base(dest))
+				result.Status = "renamed"
+			default:
+				result.ImportedAs = ""
+				result.Status = "skipped"
+				results = append(results, result)
+				continue
+			}
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return results, err
+		}
+		if result.Status != "overwritten" {
+			if err := checkSidecars(dest, ""); err != nil {
+				return results, err
+			}
+		}
+		if err := fsx.WriteFile(dest, profile.Data, 0o600); err != nil {
+			return results, err
+		}
+		if result.Status == "overwritten" {
+			if err := forgetInstall(dest); err != nil {
+				return results, err
+			}
+		}
+		// Profiles exported without a sidecar keep the local metadata.
+		if len(profile.Meta) > 0 {
+			if err := WriteMetadata(dest, profile.Meta); err != nil {
+				return results, err
+			}
+		}
+		renamed[profile.Name] = result.ImportedAs
+		results = append(results, result)
+	}
+	usage := map[string]time.Time{}
+	for name, ts := range bundle.Usage {
+		if target, ok := renamed[name]; ok {
+			usage[target] = ts
+		}
+	}
+	if err := tracker.Merge(usage); err != nil {
+		return results, err
+	}
+	history := make([]HistoryEntry, 0, len(bundle.History))
+	for _, entry := range bundle.History {
+		if target, ok := renamed[entry.Profile]; ok {
+			entry.Profile = target
+			history = append(history, entry)
+		}
+	}
+	return results, tracker.importHistory(history)
+}
+
+// bundleMetaName returns the archive name of the metadata of profile name.
+func bundleMetaName(name string) string {
+	return path.Join(path.Dir(name), ProfileStem(path.Base(name))+metadataSuffix)
+}
+
+// validBundleName accepts relative profile paths whose folders are visible
+// and whose file name is a valid profile name. Sidecar and state file names
+// are refused so an archive cannot write over them.
+func validBundleName(name string) bool {
+	if name == "" || path.IsAbs(name) || path.Clean(name) != name {
+		return false
+	}
+	parts := strings.Split(name, "/")
+	for _, part := range parts[:len(parts)-1] {
+		if part == ".." || strings.HasPrefix(part, ".") {
+			return false
+		}
+	}
+	base := parts[len(parts)-1]
+	_, err := ProfileFileName(base)
+	return err == nil && !isReservedName(base)
+}
+
+// freeProfilePath returns the first unused <stem>-N.auth.json next to dest.
+func freeProfilePath(dest string) string {
+	dir, stem := filepath.Dir(dest), ProfileStem(filepath.Base(dest))
+	for i := 2; ; i++ {
+		candidate := filepath.Join(dir, stem+"-"+strconv.Itoa(i)+profileSuffix)
+		if _, err := os.Lstat(candidate); errors.Is(err, os.ErrNotExist) {
+			return candidate
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package auth
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestValidBundleName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"work.auth.json", true},
+		{"team/work.auth.json", true},
+		{"a/b/c.json", true},
+		{"", false},
+		{"/etc/passwd.json", false},
+		{"../work.auth.json", false},
+		{"team/../../work.auth.json", false},
+		{"./work.auth.json", false},
+		{"team//work.auth.json", false},
+		{".hidden/work.auth.json", false},
+		{"team/.codex-auth-trash/work.auth.json", false},
+		{"team/", false},
+		{".codex-auth-last-used.json", false},
+		{"work.meta.yaml", false},
+		{"team/work.lease.json", false},
+		{"legacy-profile", true},
+	}
+	for _, tt := range tests {
+		if got := validBundleName(tt.name); got != tt.want {
+			t.Errorf("validBundleName(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBundleRoundTrip(t *testing.T) {
+	bundle := Bundle{
+		Manifest: BundleManifest{Version: bundleVersion, CreatedAt: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), Profiles: []string{"team/work.auth.json"}},
+		Profiles: []BundleProfile{{Name: "team/work.auth.json", Data: []byte(testAuthJSON), Meta: []byte("label: Work\n")}},
+		Usage:    map[string]time.Time{"team/work.auth.json": time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)},
+		History:  []HistoryEntry{{Time: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC), Profile: "team/work.auth.json"}},
+	}
+	raw, err := bundle.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParseBundle(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed.Profiles, bundle.Profiles) || !reflect.DeepEqual(parsed.Usage, bundle.Usage) || !reflect.DeepEqual(parsed.History, bundle.History) {
+		t.Fatalf("ParseBundle(Marshal()) = %+v, want %+v", parsed, bundle)
+	}
+}
+
+func TestParseBundleSizeLimit(t *testing.T) {
+	bundle := Bundle{
+		Manifest: BundleManifest{Version: bundleVersion, Profiles: []string{"big.auth.json"}},
+		Profiles: []BundleProfile{{Name: "big.auth.json", Data: make([]byte, MaxBundleSize+1)}},
+	}
+	raw, err := bundle.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseBundle(raw); !errors.Is(err, errBundleTooLarge) {
+		t.Fatalf("ParseBundle error = %v, want %v", err, errBundleTooLarge)
+	}
+}
+
+func TestImportBundleConflicts(t *testing.T) {
+	imported := []byte(`{"tokens":{"account_id":"imported"}}`)
+	existing := []byte(`{"tokens":{"account_id":"existing"}}`)
+	used := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	bundle := Bundle{
+		Profiles: []BundleProfile{
+			{Name: "work.auth.json", Data: imported},
+			{Name: "team/new.auth.json", Data: imported},
+		},
+		Usage: map[string]time.Time{"work.auth.json": used, "team/new.auth.json": used},
+	}
+	tests := []struct {
+		policy   ConflictPolicy
+		want     []ImportResult
+		wantWork []byte
+		// wantUsage lists the names that received the bundled timestamp.
+		wantUsage []string
+	}{
+		{
+			policy:    ConflictSkip,
+			want:      []ImportResult{{Profile: "work.auth.json", Status: "skipped"}, {Profile: "team/new.auth.json", ImportedAs: "team/new.auth.json", Status: "imported"}},
+			wantWork:  existing,
+			wantUsage: []string{"team/new.auth.json"},
+		},
+		{
+			policy:    ConflictRename,
+			want:      []ImportResult{{Profile: "work.auth.json", ImportedAs: "work-2.auth.json", Status: "renamed"}, {Profile: "team/new.auth.json", ImportedAs: "team/new.auth.json", Status: "imported"}},
+			wantWork:  existing,
+			wantUsage: []string{"work-2.auth.json", "team/new.auth.json"},
+		},
+		{
+			policy:    ConflictOverwrite,
+			want:      []ImportResult{{Profile: "work.auth.json", ImportedAs: "work.auth.json", Status: "overwritten"}, {Profile: "team/new.auth.json", ImportedAs: "team/new.auth.json", Status: "imported"}},
+			wantWork:  imported,
+			wantUsage: []string{"work.auth.json", "team/new.auth.json"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			root := t.TempDir()
+			if err := os.WriteFile(filepath.Join(root, "work.auth.json"), existing, 0o600); err != nil {
+				t.Fatal(err)
+			}
+			tracker, err := LoadUsageTracker(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			results, err := ImportBundle(root, bundle, tt.policy, tracker)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(results, tt.want) {
+				t.Errorf("results = %+v, want %+v", results, tt.want)
+			}
+			work, err := os.ReadFile(filepath.Join(root, "work.auth.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(work) != string(tt.wantWork) {
+				t.Errorf("work.auth.json = %s, want %s", work, tt.wantWork)
+			}
+			for _, name := range tt.wantUsage {
+				if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(name))); err != nil {
+					t.Errorf("%s was not written: %v", name, err)
+				}
+				if got := tracker.LastUsed(name); !got.Equal(used) {
+					t.Errorf("LastUsed(%s) = %v, want %v", name, got, used)
+				}
+			}
+			if tt.policy == ConflictSkip && !tracker.LastUsed("work.auth.json").IsZero() {
+				t.Error("skipped profile received the bundled usage")
+			}
+		})
+	}
+}
+
+func TestImportBundleOverwriteInstalled(t *testing.T) {
+	t.Setenv(CodexHomeEnv, t.TempDir())
+	root := t.TempDir()
+	dest := filepath.Join(root, "work.auth.json")
+	existing := []byte(`{"tokens":{"account_id":"existing"}}`)
+	if err := os.WriteFile(dest, existing, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(MetadataPath(dest), []byte("label: Work\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := recordInstall(dest, existing, existing, false); err != nil {
+		t.Fatal(err)
+	}
+	tracker, err := LoadUsageTracker(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle := Bundle{Profiles: []BundleProfile{{Name: "work.auth.json", Data: []byte(`{"tokens":{"account_id":"imported"}}`)}}}
+	if _, err := ImportBundle(root, bundle, ConflictOverwrite, tracker); err != nil {
+		t.Fatal(err)
+	}
+	if meta, err := os.ReadFile(MetadataPath(dest)); err != nil || string(meta) != "label: Work\n" {
+		t.Errorf("metadata = %q, %v; want it kept", meta, err)
+	}
+	if _, ok, err := LoadInstallState(); err != nil || ok {
+		t.Errorf("install state still recorded (ok=%v, err=%v)", ok, err)
+	}
+}
+
+func TestParseConflictPolicy(t *testing.T) {
+	for _, value := range []string{"skip", "rename", "overwrite"} {
+		if got, err := ParseConflictPolicy(value); err != nil || string(got) != value {
+			t.Errorf("ParseConflictPolicy(%q) = %q, %v", value, got, err)
+		}
+	}
+	if _, err := ParseConflictPolicy("merge"); err == nil {
+		t.Error("ParseConflictPolicy accepted merge")
+	}
+}
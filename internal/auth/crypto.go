@@ -0,0 +1,231 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"codex-control/internal/fsx"
+)
+
+const (
+	envelopeVersion  = 1
+	envelopeKDF      = "pbkdf2-sha256"
+	envelopeCipher   = "aes-256-gcm"
+	kdfIterations    = 600_000
+	kdfSaltSize      = 16
+	envelopeAADLabel = "codex-auth-encrypted-v1"
+)
+
+var (
+	// ErrPassphraseRequired reports that an encrypted profile was used without
+	// a way to obtain the passphrase.
+	ErrPassphraseRequired = errors.New("passphrase required for encrypted profile")
+	// ErrDecrypt reports a wrong passphrase or a tampered profile.
+	ErrDecrypt = errors.New("cannot decrypt profile: wrong passphrase or corrupted file")
+)
+
+// PassphraseFunc returns the passphrase used to encrypt and decrypt profiles.
+type PassphraseFunc func() ([]byte, error)
+
+type envelope struct {
+	Version    int    `json:"codex_auth_encrypted"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Cipher     string `json:"cipher"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// IsEncrypted reports whether raw holds an encrypted profile envelope.
+func IsEncrypted(raw []byte) bool {
+	var probe struct {
+		Version int `json:"codex_auth_encrypted"`
+	}
+	if err := json.Unmarshal(raw, &probe); err != nil {
+		return false
+	}
+	return probe.Version > 0
+}
+
+// Encrypt seals plain with a key derived from passphrase.
+func Encrypt(plain, passphrase []byte) ([]byte, error) {
+	if len(passphrase) == 0 {
+		return nil, ErrPassphraseRequired
+	}
+	salt := make([]byte, kdfSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(passphrase, salt, kdfIterations)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	env := envelope{
+		Version:    envelopeVersion,
+		KDF:        envelopeKDF,
+		Iterations: kdfIterations,
+		Cipher:     envelopeCipher,
+		Salt:       salt,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, plain, []byte(envelopeAADLabel)),
+	}
+	return json.MarshalIndent(env, "", "  ")
+}
+
+// Decrypt opens an envelope produced by Encrypt.
+func Decrypt(raw, passphrase []byte) ([]byte, error) {
+	var env envelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return nil, err
+	}
+	if env.Version != envelopeVersion || env.KDF != envelopeKDF || env.Cipher != envelopeCipher {
+		return nil, fmt.Errorf("unsupported encrypted profile format (version %d, %s, %s)", env.Version, env.KDF, env.Cipher)
+	}
+	if len(passphrase) == 0 {
+		return nil, ErrPassphraseRequired
+	}
+	gcm, err := newGCM(passphrase, env.Salt, env.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	if len(env.Nonce) != gcm.NonceSize() {
+		return nil, ErrDecrypt
+	}
+	plain, err := gcm.Open(nil, env.Nonce, env.Ciphertext, []byte(envelopeAADLabel))
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plain, nil
+}
+
+// EncryptProfile rewrites a plaintext profile in encrypted form. It returns
+// false when the profile was already encrypted.
+func EncryptProfile(path string, passphrase PassphraseFunc) (bool, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	if IsEncrypted(raw) {
+		return false, nil
+	}
+	if err := writeProfile(path, raw, true, passphrase); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// DecryptProfile rewrites an encrypted profile as plaintext. It returns false
+// when the profile was not encrypted.
+func DecryptProfile(path string, passphrase PassphraseFunc) (bool, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	if !IsEncrypted(raw) {
+		return false, nil
+	}
+	plain, _, err := openProfile(raw, passphrase)
+	if err != nil {
+		return false, err
+	}
+	if err := writeProfile(path, plain, false, passphrase); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// readProfile returns the plaintext auth.json stored at path along with the
+// raw bytes on disk.
+func readProfile(path string, passphrase PassphraseFunc) (plain, raw []byte, err error) {
+	raw, err = os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	plain, _, err = openProfile(raw, passphrase)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return plain, raw, nil
+}
+
+func openProfile(raw []byte, passphrase PassphraseFunc) ([]byte, bool, error) {
+	if !IsEncrypted(raw) {
+		return raw, false, nil
+	}
+	if passphrase == nil {
+		return nil, true, ErrPassphraseRequired
+	}
+	secret, err := passphrase()
+	if err != nil {
+		return nil, true, err
+	}
+	plain, err := Decrypt(raw, secret)
+	return plain, true, err
+}
+
+// writeProfile stores plain at path, sealing it first when encrypted is set.
+// The installed profile record follows the new on-disk bytes so later syncs
+// do not mistake the rewrite for a conflicting change.
+func writeProfile(path string, plain []byte, encrypted bool, passphrase PassphraseFunc) error {
+	raw := plain
+	if encrypted {
+		if passphrase == nil {
+			return ErrPassphraseRequired
+		}
+		secret, err := passphrase()
+		if err != nil {
+			return err
+		}
+		raw, err = Encrypt(plain, secret)
+		if err != nil {
+			return err
+		}
+	}
+	if err := fsx.WriteFile(path, raw, 0o600); err != nil {
+		return err
+	}
+	return refreshInstallState(path, raw)
+}
+
+func newGCM(passphrase, salt []byte, iterations int) (cipher.AEAD, error) {
+	if iterations <= 0 || len(salt) == 0 {
+		return nil, errors.New("invalid key derivation parameters")
+	}
+	key, err := pbkdf2.Key(sha256.New, string(passphrase), salt, iterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// CachedPassphrase wraps fn so it is asked at most once per process.
+func CachedPassphrase(fn PassphraseFunc) PassphraseFunc {
+	var (
+		secret []byte
+		err    error
+		done   bool
+	)
+	return func() ([]byte, error) {
+		if !done {
+			secret, err = fn()
+			done = err == nil
+		}
+		return secret, err
+	}
+}
@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testAuthJSON = `{"OPENAI_API_KEY":null,"tokens":{"account_id":"acct-1"}}`
+
+func TestEncryptDecrypt(t *testing.T) {
+	plain := []byte(testAuthJSON)
+	sealed, err := Encrypt(plain, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsEncrypted(sealed) || IsEncrypted(plain) {
+		t.Fatal("IsEncrypted does not tell the envelope from plaintext")
+	}
+	if bytes.Contains(sealed, []byte("acct-1")) {
+		t.Fatal("envelope leaks the plaintext")
+	}
+	again, err := Encrypt(plain, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(sealed, again) {
+		t.Error("two encryptions produced the same envelope")
+	}
+
+	tampered := func(change func(*envelope)) []byte {
+		var env envelope
+		if err := json.Unmarshal(sealed, &env); err != nil {
+			t.Fatal(err)
+		}
+		change(&env)
+		raw, err := json.Marshal(env)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return raw
+	}
+	tests := []struct {
+		name       string
+		raw        []byte
+		passphrase string
+		wantErr    error
+		wantAnyErr bool
+	}{
+		{name: "round trip", raw: sealed, passphrase: "secret"},
+		{name: "wrong passphrase", raw: sealed, passphrase: "Secret", wantErr: ErrDecrypt},
+		{name: "empty passphrase", raw: sealed, passphrase: "", wantErr: ErrPassphraseRequired},
+		{name: "flipped ciphertext", raw: tampered(func(e *envelope) { e.Ciphertext[0] ^= 1 }), passphrase: "secret", wantErr: ErrDecrypt},
+		{name: "other salt", raw: tampered(func(e *envelope) { e.Salt[0] ^= 1 }), passphrase: "secret", wantErr: ErrDecrypt},
+		{name: "short nonce", raw: tampered(func(e *envelope) { e.Nonce = e.Nonce[:4] }), passphrase: "secret", wantErr: ErrDecrypt},
+		{name: "unknown version", raw: tampered(func(e *envelope) { e.Version = 2 }), passphrase: "secret", wantAnyErr: true},
+		{name: "unknown cipher", raw: tampered(func(e *envelope) { e.Cipher = "chacha20" }), passphrase: "secret", wantAnyErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decrypt(tt.raw, []byte(tt.passphrase))
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Decrypt error = %v, want %v", err, tt.wantErr)
+				}
+			case tt.wantAnyErr:
+				if err == nil {
+					t.Fatal("Decrypt succeeded, want an error")
+				}
+			case err != nil:
+				t.Fatalf("Decrypt: %v", err)
+			case !bytes.Equal(got, plain):
+				t.Fatalf("Decrypt = %s, want %s", got, plain)
+			}
+		})
+	}
+}
+
+func TestEncryptRequiresPassphrase(t *testing.T) {
+	if _, err := Encrypt([]byte(testAuthJSON), nil); !errors.Is(err, ErrPassphraseRequired) {
+		t.Fatalf("Encrypt without passphrase = %v, want ErrPassphraseRequired", err)
+	}
+}
+
+func TestEncryptProfileRoundTrip(t *testing.T) {
+	t.Setenv(CodexHomeEnv, t.TempDir())
+	path := filepath.Join(t.TempDir(), "work.auth.json")
+	if err := os.WriteFile(path, []byte(testAuthJSON), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	passphrase := func() ([]byte, error) { return []byte("secret"), nil }
+
+	steps := []struct {
+		name        string
+		run         func(string, PassphraseFunc) (bool, error)
+		wantChanged bool
+		wantSealed  bool
+	}{
+		{"encrypt", EncryptProfile, true, true},
+		{"encrypt again", EncryptProfile, false, true},
+		{"decrypt", DecryptProfile, true, false},
+		{"decrypt again", DecryptProfile, false, false},
+	}
+	for _, step := range steps {
+		changed, err := step.run(path, passphrase)
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if changed != step.wantChanged || IsEncrypted(raw) != step.wantSealed {
+			t.Fatalf("%s: changed %v, encrypted %v; want %v, %v", step.name, changed, IsEncrypted(raw), step.wantChanged, step.wantSealed)
+		}
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(raw) != testAuthJSON {
+		t.Fatalf("profile after round trip = %s, want %s", raw, testAuthJSON)
+	}
+}
@@ -0,0 +1,175 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Issue kinds reported by Diagnose.
+const (
+	IssuePermissions = "permissions"
+	IssueInvalid     = "invalid"
+	IssueSidecar     = "sidecar"
+)
+
+// Issue is a problem Diagnose found with a file codex-auth manages.
+type Issue struct {
+	Path   string `json:"path"`
+	Kind   string `json:"kind"`
+	Detail string `json:"detail"`
+	Fixed  bool   `json:"fixed,omitempty"`
+	mode   fs.FileMode
+}
+
+// Fixable reports whether Fix can resolve the issue.
+func (i Issue) Fixable() bool {
+	return i.Kind == IssuePermissions
+}
+
+// Fix removes group and other access from the path of a permissions issue.
+func (i *Issue) Fix() error {
+	if !i.Fixable() {
+		return fmt.Errorf("%s cannot be fixed automatically", i.Path)
+	}
+	if err := os.Chmod(i.Path, i.mode); err != nil {
+		return err
+	}
+	i.Fixed = true
+	return nil
+}
+
+// Diagnose checks the auths roots, the live auth.json and its backups for
+// files and folders that group or others can access, and reports profiles
+// that do not validate as auth documents or share their sidecar files with
+// another profile. Symlinks and ignored files are left alone.
+func Diagnose(roots []string) ([]Issue, error) {
+	var issues []Issue
+	for _, root := range roots {
+		found, err := checkTree(root)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, found...)
+		files, err := ListFiles(root)
+		if err != nil {
+			return nil, err
+		}
+		// Profiles with the same stem, such as foo.json and foo.auth.json,
+		// resolve to the same metadata and lease files.
+		sidecars := map[string][]string{}
+		for _, file := range files {
+			meta := MetadataPath(file.Path)
+			sidecars[meta] = append(sidecars[meta], filepath.Base(file.Path))
+		}
+		for _, file := range files {
+			if file.Invalid != "" {
+				issues = append(issues, Issue{Path: file.Path, Kind: IssueInvalid, Detail: file.Invalid})
+			}
+			if shared := sidecars[MetadataPath(file.Path)]; len(shared) > 1 {
+				issues = append(issues, Issue{Path: file.Path, Kind: IssueSidecar, Detail: sidecarDetail(file.Path, shared)})
+			}
+		}
+	}
+	live, err := LiveAuthPath()
+	if err != nil {
+		return nil, err
+	}
+	state, err := installStatePath()
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range []string{live, state} {
+		info, err := os.Lstat(path)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		if issue, ok := checkMode(path, info.Mode()); ok {
+			issues = append(issues, issue)
+		}
+	}
+	backups, err := backupDir()
+	if err != nil {
+		return nil, err
+	}
+	found, err := checkTree(backups)
+	if err != nil {
+		return nil, err
+	}
+	return append(issues, found...), nil
+}
+
+// sidecarDetail describes the profiles path shares its sidecar files with.
+func sidecarDetail(path string, shared []string) string {
+	others := make([]string, 0, len(shared)-1)
+	for _, name := range shared {
+		if name != filepath.Base(path) {
+			others = append(others, name)
+		}
+	}
+	return fmt.Sprintf("shares %s and %s with %s; rename one of them", filepath.Base(MetadataPath(path)), filepath.Base(LeasePath(path)), strings.Join(others, ", "))
+}
+
+// checkTree reports every folder and file below root, root included, whose
+// permissions are too open. A missing root yields no issues.
+func checkTree(root string) ([]Issue, error) {
+	var issues []Issue
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root && errors.Is(err, os.ErrNotExist) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if entry.Type()&fs.ModeSymlink != 0 {
+			return nil
+		}
+		if !entry.IsDir() {
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			if isIgnored(filepath.ToSlash(rel)) {
+				return nil
+			}
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if issue, ok := checkMode(path, info.Mode()); ok {
+			issues = append(issues, issue)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return issues, nil
+}
+
+// checkMode reports a permissions issue when mode grants group or others any
+// access.
+func checkMode(path string, mode fs.FileMode) (Issue, bool) {
+	perm := mode.Perm()
+	if perm&0o077 == 0 {
+		return Issue{}, false
+	}
+	want := perm &^ 0o077
+	kind := "file"
+	if mode.IsDir() {
+		kind = "folder"
+	}
+	return Issue{
+		Path:   path,
+		Kind:   IssuePermissions,
+		Detail: fmt.Sprintf("%s mode %04o gives group or others access; want %04o", kind, perm, want),
+		mode:   want,
+	}, true
+}
@@ -1,23 +1,49 @@
 package auth
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"time"
 
 	"codex-control/internal/fsx"
 )
 
-const usageStateFile = ".codex-auth-last-used.json"
+const (
+	usageStateFile = ".codex-auth-last-used.json"
+	profileSuffix  = ".auth.json"
+)
+
+// ErrProfileExists reports that saving would overwrite an existing profile.
+var ErrProfileExists = errors.New("profile already exists")
+
+// ErrSidecarConflict reports that two profiles in one folder share a stem,
+// such as foo.json and foo.auth.json, and with it their metadata and lease
+// files.
+var ErrSidecarConflict = errors.New("profile shares its metadata and lease files with another profile")
 
-// File represents an auth file candidate.
+// File represents an auth file candidate. Name is the path relative to its
+// root, using / separators, and is prefixed with "<root>:" when the same name
+// exists in several roots. Group is the subfolder the file was found in.
 type File struct {
-	Name    string
-	Path    string
-	Size    int64
-	ModTime time.Time
+	Name      string
+	Path      string
+	Root      string
+	Group     string
+	Size      int64
+	ModTime   time.Time
+	Identity  Identity
+	Encrypted bool
+	Meta      Metadata
+	// Invalid explains why the file does not look like a Codex auth file; it
+	// is empty for usable profiles.
+	Invalid string
+	// Lease is the unexpired lease on the profile, if any.
+	Lease *Lease
 }
 
 // CopyResult describes the installed auth file.
@@ -25,32 +51,70 @@ type CopyResult struct {
 	Source      string `json:"source"`
 	Destination string `json:"destination"`
 	Bytes       int64  `json:"bytes"`
+	Backup      string `json:"backup,omitempty"`
 }
 
-// ListFiles scans the provided directory for regular files.
+// ListFiles scans the provided directory and its subfolders for regular
+// files, decodes the account identity of each one and merges its metadata
+// sidecar and lease. Hidden subfolders and entries matching the ignore
+// patterns are skipped. Files that do not validate as auth documents are
+// listed with Invalid set; encrypted contents leave Identity empty.
 func ListFiles(root string) ([]File, error) {
-	entries, err := os.ReadDir(root)
-	if err != nil {
-		return nil, err
-	}
-	files := make([]File, 0, len(entries))
-	for _, entry := range entries {
+	files := []File{}
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
 		if entry.IsDir() {
-			continue
+			if path != root && (strings.HasPrefix(entry.Name(), ".") || isIgnored(filepath.ToSlash(rel))) {
+				return filepath.SkipDir
+			}
+			return nil
 		}
-		if entry.Name() == usageStateFile {
-			continue
+		if isReservedName(entry.Name()) || isIgnored(filepath.ToSlash(rel)) || !entry.Type().IsRegular() && entry.Type()&fs.ModeSymlink == 0 {
+			return nil
 		}
-		info, err := entry.Info()
-		if err != nil {
-			return nil, err
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			return nil
 		}
-		files = append(files, File{
-			Name:    entry.Name(),
-			Path:    filepath.Join(root, entry.Name()),
+		file := File{
+			Name:    filepath.ToSlash(rel),
+			Path:    path,
+			Root:    root,
+			Group:   filepath.ToSlash(filepath.Dir(rel)),
 			Size:    info.Size(),
 			ModTime: info.ModTime(),
-		})
+		}
+		if file.Group == "." {
+			file.Group = ""
+		}
+		raw, err := os.ReadFile(path)
+		switch {
+		case err != nil:
+			file.Invalid = err.Error()
+		case IsEncrypted(raw):
+			file.Encrypted = true
+		default:
+			if err := ValidateAuth(raw); err != nil {
+				file.Invalid = err.Error()
+			} else {
+				file.Identity, _ = ParseIdentity(raw)
+			}
+		}
+		file.Meta, _ = LoadMetadata(path)
+		if lease, ok, err := LoadLease(path); err == nil && ok {
+			file.Lease = &lease
+		}
+		files = append(files, file)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	sort.Slice(files, func(i, j int) bool {
 		return files[i].Name < files[j].Name
@@ -58,25 +122,240 @@ func ListFiles(root string) ([]File, error) {
 	return files, nil
 }
 
-// Install copies the auth file into ~/.codex/auth.json.
-func Install(src string) (CopyResult, error) {
+// ListRoots lists the profiles of every root. Names found in more than one
+// root are prefixed with the shortest trailing part of the root path that
+// tells the roots apart, such as "work/auths:x.auth.json".
+func ListRoots(roots []string) ([]File, error) {
+	var files []File
+	counts := map[string]int{}
+	labels := rootLabels(roots)
+	prefixes := map[string]string{}
+	for i, root := range roots {
+		found, err := ListFiles(root)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range found {
+			counts[file.Name]++
+		}
+		files = append(files, found...)
+		prefixes[root] = labels[i]
+	}
+	for i, file := range files {
+		if counts[file.Name] > 1 {
+			files[i].Name = prefixes[file.Root] + ":" + file.Name
+		}
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].Name < files[j].Name
+	})
+	return files, nil
+}
+
+// Stem returns the file name of the profile without its group, root prefix
+// or .auth.json suffix.
+func (f File) Stem() string {
+	return ProfileStem(filepath.Base(f.Path))
+}
+
+// rootLabels names each root by its last path elements, adding parent
+// folders until every label is unique. Roots that stay alike, such as the
+// same folder listed twice, fall back to their position.
+func rootLabels(roots []string) []string {
+	labels := make([]string, len(roots))
+	for depth := 1; ; depth++ {
+		seen := map[string]int{}
+		complete := true
+		for i, root := range roots {
+			parts := strings.Split(filepath.ToSlash(filepath.Clean(root)), "/")
+			if depth < len(parts) {
+				complete = false
+				parts = parts[len(parts)-depth:]
+			}
+			labels[i] = strings.Trim(strings.Join(parts, "/"), "/")
+			seen[labels[i]]++
+		}
+		unique := true
+		for _, n := range seen {
+			unique = unique && n == 1
+		}
+		if unique {
+			return labels
+		}
+		if complete {
+			for i := range labels {
+				if seen[labels[i]] > 1 {
+					labels[i] = fmt.Sprintf("%s#%d", labels[i], i+1)
+				}
+			}
+			return labels
+		}
+	}
+}
+
+// RelPath returns the location of the file inside its root with / separators.
+func (f File) RelPath() string {
+	if f.Group == "" {
+		return filepath.Base(f.Path)
+	}
+	return f.Group + "/" + filepath.Base(f.Path)
+}
+
+// CodexDir returns the directory Codex keeps its state in: CODEX_HOME when
+// set, ~/.codex otherwise.
+func CodexDir() (string, error) {
+	if dir := os.Getenv(CodexHomeEnv); dir != "" {
+		return filepath.Abs(dir)
+	}
 	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".codex"), nil
+}
+
+// LiveAuthPath returns the location Codex reads credentials from.
+func LiveAuthPath() (string, error) {
+	dir, err := CodexDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "auth.json"), nil
+}
+
+// Install copies the auth file over the live auth.json in CodexDir() and
+// remembers the source so refreshed tokens can be synced back later.
+// Encrypted profiles are decrypted on the fly and API key profiles are turned
+// into an API key auth.json. The replaced auth.json is kept as a rotating
+// backup.
+func Install(src string, opts InstallOptions) (CopyResult, error) {
+	dest, err := LiveAuthPath()
+	if err != nil {
+		return CopyResult{}, err
+	}
+	unlock, err := lockLive()
 	if err != nil {
 		return CopyResult{}, err
 	}
-	destDir := filepath.Join(home, ".codex")
-	if err := os.MkdirAll(destDir, 0o700); err != nil {
+	defer unlock()
+	if err := os.MkdirAll(filepath.Dir(dest), 0o700); err != nil {
 		return CopyResult{}, err
 	}
-	dest := filepath.Join(destDir, "auth.json")
-	info, err := os.Stat(src)
+	plain, raw, err := readProfile(src, opts.Passphrase)
 	if err != nil {
 		return CopyResult{}, err
 	}
-	if err := fsx.CopyFile(src, dest, 0o600); err != nil {
+	content, err := materialize(plain)
+	if err != nil {
+		return CopyResult{}, fmt.Errorf("%s: %w", src, err)
+	}
+	saved, err := backupLive(content, opts.backupLimit())
+	if err != nil {
+		return CopyResult{}, fmt.Errorf("backup of %s failed: %w", dest, err)
+	}
+	if err := fsx.WriteFile(dest, content, 0o600); err != nil {
 		return CopyResult{}, err
 	}
-	return CopyResult{Source: src, Destination: dest, Bytes: info.Size()}, nil
+	if err := recordInstall(src, content, raw, IsAPIProfile(plain)); err != nil {
+		return CopyResult{}, err
+	}
+	return CopyResult{Source: src, Destination: dest, Bytes: int64(len(content)), Backup: saved}, nil
+}
+
+// Save snapshots the live auth.json in CodexDir() into root under the given
+// profile name. Names without a .json suffix receive the .auth.json suffix.
+// Existing profiles are only replaced when force is set and keep their
+// encryption.
+func Save(root, name string, force bool, opts InstallOptions) (CopyResult, error) {
+	fileName, err := ProfileFileName(name)
+	if err != nil {
+		return CopyResult{}, err
+	}
+	return SaveTo(filepath.Join(root, fileName), force, opts)
+}
+
+// SaveTo snapshots the live auth.json in CodexDir() into the profile file at
+// dest, which may live in any root or group folder. An existing file is only
+// replaced when force is set and keeps its encryption.
+func SaveTo(dest string, force bool, opts InstallOptions) (CopyResult, error) {
+	src, err := LiveAuthPath()
+	if err != nil {
+		return CopyResult{}, err
+	}
+	unlock, err := lockLive()
+	if err != nil {
+		return CopyResult{}, err
+	}
+	defer unlock()
+	content, err := os.ReadFile(src)
+	if err != nil {
+		return CopyResult{}, fmt.Errorf("no live credentials to save: %w", err)
+	}
+	if err := checkSidecars(dest, ""); err != nil {
+		return CopyResult{}, err
+	}
+	encrypted := false
+	if existing, err := os.ReadFile(dest); err == nil {
+		if !force {
+			return CopyResult{}, fmt.Errorf("%w: %s", ErrProfileExists, dest)
+		}
+		encrypted = IsEncrypted(existing)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return CopyResult{}, err
+	}
+	if err := writeProfile(dest, content, encrypted, opts.Passphrase); err != nil {
+		return CopyResult{}, err
+	}
+	raw, err := os.ReadFile(dest)
+	if err != nil {
+		return CopyResult{}, err
+	}
+	if err := recordInstall(dest, content, raw, false); err != nil {
+		return CopyResult{}, err
+	}
+	return CopyResult{Source: src, Destination: dest, Bytes: int64(len(content))}, nil
+}
+
+// ProfileFileName validates a user supplied profile name and returns the file
+// name it is stored under.
+func ProfileFileName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid profile name %q", name)
+	}
+	if strings.ContainsRune(name, '/') || strings.ContainsRune(name, filepath.Separator) {
+		return "", fmt.Errorf("profile name %q must not contain path separators", name)
+	}
+	if !strings.HasSuffix(name, ".json") {
+		name += profileSuffix
+	}
+	if isReservedName(name) {
+		return "", fmt.Errorf("profile name %q is reserved", name)
+	}
+	return name, nil
+}
+
+// checkSidecars refuses dest when another profile in its folder has the same
+// stem, since both would read and write the same metadata and lease files.
+// replaced names a profile that dest takes the place of, such as the source
+// of a rename, and is not counted.
+func checkSidecars(dest, replaced string) error {
+	base := filepath.Base(dest)
+	entries, err := os.ReadDir(filepath.Dir(dest))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || name == base || filepath.Join(filepath.Dir(dest), name) == replaced || isReservedName(name) || ProfileStem(name) != ProfileStem(base) {
+			continue
+		}
+		return fmt.Errorf("%w: %s and %s", ErrSidecarConflict, base, name)
+	}
+	return nil
 }
 
 // ValidateRoot ensures the directory exists and returns the folder that actually stores auth files.
@@ -113,19 +392,48 @@ func ValidateRoot(path string) (string, error) {
 	return "", fmt.Errorf("no auth files found inside %s", cleaned)
 }
 
-func dirHasFiles(path string) (bool, error) {
-	entries, err := os.ReadDir(path)
+// ValidateSaveRoot resolves the folder new profiles are written to. Unlike
+// ValidateRoot it accepts an empty directory so the first profile can be saved.
+func ValidateSaveRoot(path string) (string, error) {
+	if root, err := ValidateRoot(path); err == nil {
+		return root, nil
+	}
+	if path == "" {
+		return "", fmt.Errorf("auths path is not set; set --auths-path or update the config file")
+	}
+	cleaned := filepath.Clean(path)
+	info, err := os.Stat(cleaned)
 	if err != nil {
-		return false, err
+		return "", err
 	}
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
-		}
-		if entry.Name() == usageStateFile {
-			continue
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", cleaned)
+	}
+	if filepath.Base(cleaned) != "auths" {
+		candidate := filepath.Join(cleaned, "auths")
+		if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+			return candidate, nil
 		}
-		return true, nil
 	}
-	return false, nil
+	return cleaned, nil
+}
+
+// isReservedName reports whether a file in the auth directory belongs to
+// codex-auth itself rather than being a profile.
+func isReservedName(name string) bool {
+	switch name {
+	case usageStateFile, installStateFile, historyFile, lockFile:
+		return true
+	}
+	return strings.HasSuffix(name, metadataSuffix) || strings.HasSuffix(name, leaseSuffix)
+}
+
+// dirHasFiles reports whether path or one of its non-hidden subfolders holds
+// a profile.
+func dirHasFiles(path string) (bool, error) {
+	files, err := ListFiles(path)
+	if err != nil {
+		return false, err
+	}
+	return len(files) > 0, nil
 }
@@ -0,0 +1,161 @@
+package auth
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/user"
+	"path/filepath"
+	"time"
+
+	"codex-control/internal/fsx"
+)
+
+const historyFile = ".codex-auth-history.jsonl"
+
+// HistoryEntry records a single profile switch.
+type HistoryEntry struct {
+	Time    time.Time `json:"time"`
+	Profile string    `json:"profile"`
+	Host    string    `json:"host,omitempty"`
+	User    string    `json:"user,omitempty"`
+	Cwd     string    `json:"cwd,omitempty"`
+	Command string    `json:"command,omitempty"`
+}
+
+// Record marks the profile as used at ts and appends a switch to the history
+// log stored next to the last-used data. trigger names the command that
+// caused the switch.
+func (t *UsageTracker) Record(name, trigger string, ts time.Time) error {
+	if t == nil {
+		return nil
+	}
+	if err := t.Touch(name, ts); err != nil {
+		return err
+	}
+	entry := HistoryEntry{Time: ts.UTC(), Profile: name, Command: trigger}
+	entry.Host, _ = os.Hostname()
+	if current, err := user.Current(); err == nil {
+		entry.User = current.Username
+	}
+	entry.Cwd, _ = os.Getwd()
+	return t.appendHistory(entry)
+}
+
+// History returns every recorded switch in the order it was written.
+// Malformed lines are skipped.
+func (t *UsageTracker) History() ([]HistoryEntry, error) {
+	if t == nil {
+		return nil, nil
+	}
+	file, err := os.Open(t.historyPath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+	var entries []HistoryEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil || entry.Profile == "" {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+func (t *UsageTracker) appendHistory(entry HistoryEntry) error {
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	unlock, err := lockDir(filepath.Dir(t.path))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	file, err := os.OpenFile(t.historyPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(raw, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// renameHistory points the entries of a renamed profile at its new name.
+// Lines that cannot be decoded are kept as they are.
+func (t *UsageTracker) renameHistory(from, to string) error {
+	unlock, err := lockDir(filepath.Dir(t.path))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	raw, err := os.ReadFile(t.historyPath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	lines := bytes.SplitAfter(raw, []byte("\n"))
+	changed := false
+	for i, line := range lines {
+		var entry HistoryEntry
+		if err := json.Unmarshal(line, &entry); err != nil || entry.Profile != from {
+			continue
+		}
+		entry.Profile = to
+		encoded, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		lines[i] = append(encoded, '\n')
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	return fsx.WriteFile(t.historyPath(), bytes.Join(lines, nil), 0o600)
+}
+
+// importHistory appends entries that are not recorded yet, identified by
+// time and profile.
+func (t *UsageTracker) importHistory(entries []HistoryEntry) error {
+	if t == nil || len(entries) == 0 {
+		return nil
+	}
+	existing, err := t.History()
+	if err != nil {
+		return err
+	}
+	type key struct {
+		time    time.Time
+		profile string
+	}
+	seen := make(map[key]struct{}, len(existing))
+	for _, entry := range existing {
+		seen[key{entry.Time.UTC(), entry.Profile}] = struct{}{}
+	}
+	for _, entry := range entries {
+		if _, ok := seen[key{entry.Time.UTC(), entry.Profile}]; ok {
+			continue
+		}
+		if err := t.appendHistory(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *UsageTracker) historyPath() string {
+	return filepath.Join(filepath.Dir(t.path), historyFile)
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// CodexHomeEnv is the variable Codex reads its state directory from.
+const CodexHomeEnv = "CODEX_HOME"
+
+// sharedHomeEntries are linked from the main Codex home into isolated homes so
+// every profile runs with the same configuration.
+var sharedHomeEntries = []string{"config.toml", "prompts"}
+
+// SetCodexHome points this process, and the Codex processes it starts, at
+// dir. An empty dir leaves the environment untouched.
+func SetCodexHome(dir string) error {
+	if dir == "" {
+		return nil
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	return os.Setenv(CodexHomeEnv, abs)
+}
+
+// DefaultHomesDir returns the folder holding isolated per-profile homes.
+func DefaultHomesDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".codex-homes"), nil
+}
+
+// IsolatedHome creates the Codex home dedicated to file inside homesDir and
+// returns its path. Entries of sharedHomeEntries found in the shared home are
+// symlinked into it; entries that already exist in the isolated home are left
+// alone so a profile can override them.
+func IsolatedHome(homesDir string, file File, shared string) (string, error) {
+	dir := filepath.Join(homesDir, ProfileStem(file.Name))
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	for _, name := range sharedHomeEntries {
+		target := filepath.Join(shared, name)
+		if _, err := os.Stat(target); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return "", err
+		}
+		link := filepath.Join(dir, name)
+		if _, err := os.Lstat(link); err == nil {
+			continue
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+		if err := os.Symlink(target, link); err != nil {
+			return "", err
+		}
+	}
+	return dir, nil
+}
@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Identity summarizes the account stored inside an auth file.
+type Identity struct {
+	Email       string
+	AccountID   string
+	Plan        string
+	ExpiresAt   time.Time
+	LastRefresh time.Time
+	APIKey      bool
+	// Provider is the custom model provider of an API key profile.
+	Provider string
+}
+
+// Expired reports whether the stored access token expired before now.
+func (i Identity) Expired(now time.Time) bool {
+	return !i.ExpiresAt.IsZero() && i.ExpiresAt.Before(now)
+}
+
+type authDocument struct {
+	APIKey      *string      `json:"OPENAI_API_KEY"`
+	Tokens      *tokenFields `json:"tokens"`
+	LastRefresh string       `json:"last_refresh"`
+}
+
+type tokenFields struct {
+	IDToken      string `json:"id_token"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	AccountID    string `json:"account_id"`
+}
+
+type tokenClaims struct {
+	Email string `json:"email"`
+	Exp   int64  `json:"exp"`
+	Auth  struct {
+		Plan      string `json:"chatgpt_plan_type"`
+		AccountID string `json:"chatgpt_account_id"`
+	} `json:"https://api.openai.com/auth"`
+	Profile struct {
+		Email string `json:"email"`
+	} `json:"https://api.openai.com/profile"`
+}
+
+// ReadIdentity parses the auth file at path.
+func ReadIdentity(path string) (Identity, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return Identity{}, err
+	}
+	return ParseIdentity(raw)
+}
+
+// ParseIdentity decodes an auth.json document. Token claims are read without
+// verifying signatures; they only describe the account for display purposes.
+func ParseIdentity(raw []byte) (Identity, error) {
+	if IsAPIProfile(raw) {
+		profile, err := ParseAPIProfile(raw)
+		if err != nil {
+			return Identity{}, err
+		}
+		id := Identity{APIKey: true}
+		if profile.Provider != nil {
+			id.Provider = profile.Provider.ID
+		}
+		return id, nil
+	}
+	var doc authDocument
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return Identity{}, err
+	}
+	var id Identity
+	if doc.APIKey != nil && strings.TrimSpace(*doc.APIKey) != "" {
+		id.APIKey = true
+	}
+	if doc.LastRefresh != "" {
+		if ts, err := time.Parse(time.RFC3339Nano, doc.LastRefresh); err == nil {
+			id.LastRefresh = ts
+		}
+	}
+	if doc.Tokens == nil {
+		return id, nil
+	}
+	id.AccountID = doc.Tokens.AccountID
+	idClaims, _ := decodeClaims(doc.Tokens.IDToken)
+	accessClaims, _ := decodeClaims(doc.Tokens.AccessToken)
+	id.Email = firstNonEmpty(idClaims.Email, accessClaims.Profile.Email)
+	id.Plan = firstNonEmpty(idClaims.Auth.Plan, accessClaims.Auth.Plan)
+	if id.AccountID == "" {
+		id.AccountID = firstNonEmpty(idClaims.Auth.AccountID, accessClaims.Auth.AccountID)
+	}
+	switch {
+	case accessClaims.Exp > 0:
+		id.ExpiresAt = time.Unix(accessClaims.Exp, 0)
+	case idClaims.Exp > 0:
+		id.ExpiresAt = time.Unix(idClaims.Exp, 0)
+	}
+	return id, nil
+}
+
+// ValidateAuth checks that raw looks like a Codex auth.json: a JSON object
+// holding either an API key or a token set with access and refresh tokens.
+// API key profiles are validated as such.
+func ValidateAuth(raw []byte) error {
+	if IsAPIProfile(raw) {
+		_, err := ParseAPIProfile(raw)
+		return err
+	}
+	var doc authDocument
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return fmt.Errorf("not a JSON auth document: %w", err)
+	}
+	if doc.APIKey != nil && strings.TrimSpace(*doc.APIKey) != "" {
+		return nil
+	}
+	if doc.Tokens == nil {
+		return errors.New("missing OPENAI_API_KEY and tokens")
+	}
+	if doc.Tokens.AccessToken == "" || doc.Tokens.RefreshToken == "" {
+		return errors.New("tokens lack an access_token or refresh_token")
+	}
+	return nil
+}
+
+func decodeClaims(token string) (tokenClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return tokenClaims{}, errors.New("malformed JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return tokenClaims{}, err
+	}
+	var claims tokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return tokenClaims{}, err
+	}
+	return claims, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// DefaultIgnorePatterns skips editor leftovers and notes commonly kept next
+// to profiles.
+var DefaultIgnorePatterns = []string{"*~", "*.swp", "*.bak", "*.orig", ".DS_Store", "README*", "*.md", "*.txt"}
+
+var ignorePatterns = DefaultIgnorePatterns
+
+// SetIgnorePatterns replaces the glob patterns of files ListFiles skips. A
+// pattern without a slash matches the base name of files and folders, one
+// with a slash matches the path relative to the auths root.
+func SetIgnorePatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid ignore pattern %q: %w", pattern, err)
+		}
+	}
+	ignorePatterns = patterns
+	return nil
+}
+
+// isIgnored reports whether the entry at rel, relative to its root with /
+// separators, matches one of the ignore patterns.
+func isIgnored(rel string) bool {
+	base := path.Base(rel)
+	for _, pattern := range ignorePatterns {
+		target := base
+		if strings.Contains(pattern, "/") {
+			target = rel
+		}
+		if ok, _ := path.Match(pattern, target); ok {
+			return true
+		}
+	}
+	return false
+}
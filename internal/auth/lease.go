@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"time"
+
+	"codex-control/internal/fsx"
+)
+
+const (
+	leaseSuffix = ".lease.json"
+	// DefaultLeaseTTL is how long a lease lasts without being renewed.
+	DefaultLeaseTTL = 2 * time.Hour
+)
+
+// Lease marks a profile as in use by someone so teammates sharing the auths
+// directory do not run the same account concurrently. It lives in a
+// <profile>.lease.json sidecar and stops counting once it expires.
+type Lease struct {
+	Holder     string    `json:"holder"`
+	Host       string    `json:"host"`
+	PID        int       `json:"pid"`
+	AcquiredAt time.Time `json:"acquired_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// LeaseHeldError reports that another user or host holds a profile.
+type LeaseHeldError struct {
+	Profile string
+	Lease   Lease
+}
+
+func (e *LeaseHeldError) Error() string {
+	return fmt.Sprintf("%s is leased by %s@%s until %s", e.Profile, e.Lease.Holder, e.Lease.Host, e.Lease.ExpiresAt.Local().Format("2006-01-02 15:04"))
+}
+
+// Mine reports whether the lease belongs to the current user on this host.
+func (l Lease) Mine() bool {
+	holder, host := leaseOwner()
+	return l.Holder == holder && l.Host == host
+}
+
+// LeasePath returns the lease sidecar location for the auth file at path.
+func LeasePath(path string) string {
+	return filepath.Join(filepath.Dir(path), ProfileStem(filepath.Base(path))+leaseSuffix)
+}
+
+// LoadLease returns the unexpired lease on the auth file at path. The boolean
+// is false when the profile is free.
+func LoadLease(path string) (Lease, bool, error) {
+	raw, err := os.ReadFile(LeasePath(path))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return Lease{}, false, nil
+		}
+		return Lease{}, false, err
+	}
+	var lease Lease
+	if err := json.Unmarshal(raw, &lease); err != nil {
+		return Lease{}, false, err
+	}
+	if !time.Now().Before(lease.ExpiresAt) {
+		return Lease{}, false, nil
+	}
+	return lease, true, nil
+}
+
+// AcquireLease leases the auth file at path to the current user for ttl, or
+// extends the lease they already hold. A live lease of someone else yields a
+// *LeaseHeldError unless force is set, in which case it is taken over.
+func AcquireLease(path string, ttl time.Duration, force bool) (Lease, error) {
+	unlock, err := lockDir(filepath.Dir(path))
+	if err != nil {
+		return Lease{}, err
+	}
+	defer unlock()
+	current, held, err := LoadLease(path)
+	if err != nil {
+		return Lease{}, err
+	}
+	if held && !current.Mine() && !force {
+		return current, &LeaseHeldError{Profile: filepath.Base(path), Lease: current}
+	}
+	now := time.Now().UTC()
+	lease := Lease{AcquiredAt: now, ExpiresAt: now.Add(ttl), PID: os.Getpid()}
+	lease.Holder, lease.Host = leaseOwner()
+	if held && current.Mine() {
+		lease.AcquiredAt = current.AcquiredAt
+	}
+	raw, err := json.MarshalIndent(lease, "", "  ")
+	if err != nil {
+		return Lease{}, err
+	}
+	return lease, fsx.WriteFile(LeasePath(path), raw, 0o600)
+}
+
+// ReleaseLease removes the current user's lease on the auth file at path.
+// Leases held by others and expired leases are left alone.
+func ReleaseLease(path string) error {
+	unlock, err := lockDir(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	current, held, err := LoadLease(path)
+	if err != nil || !held || !current.Mine() {
+		return err
+	}
+	if err := os.Remove(LeasePath(path)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// LeasedByOther reports whether the file carries a live lease of someone else.
+func (f File) LeasedByOther() bool {
+	return f.Lease != nil && !f.Lease.Mine()
+}
+
+func leaseOwner() (string, string) {
+	holder := "unknown"
+	if current, err := user.Current(); err == nil {
+		holder = current.Username
+	}
+	host, _ := os.Hostname()
+	return holder, host
+}
@@ -0,0 +1,28 @@
+package auth
+
+import (
+	"path/filepath"
+	"time"
+
+	"codex-control/internal/fsx"
+)
+
+const lockFile = ".codex-auth.lock"
+
+// LockTimeout bounds how long a codex-auth process waits for another one to
+// finish switching profiles or updating usage data.
+var LockTimeout = 10 * time.Second
+
+// lockDir serializes codex-auth processes working on dir.
+func lockDir(dir string) (func() error, error) {
+	return fsx.Lock(filepath.Join(dir, lockFile), LockTimeout)
+}
+
+// lockLive serializes changes to the live auth.json and its install record.
+func lockLive() (func() error, error) {
+	dir, err := CodexDir()
+	if err != nil {
+		return nil, err
+	}
+	return lockDir(dir)
+}
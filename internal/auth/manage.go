@@ -0,0 +1,296 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"codex-control/internal/fsx"
+)
+
+// trashDirName is the folder inside the auths directory that deleted profiles
+// are moved to. trashStateSuffix marks the install record kept next to a
+// trashed profile that was installed, so restoring it can bring the record
+// back.
+const (
+	trashDirName     = ".codex-auth-trash"
+	trashStateSuffix = ".installed.json"
+)
+
+// RenameProfile renames the profile file and its sidecars to name inside the
+// same directory. The install record follows the rename so syncing keeps
+// working. It returns the new path.
+func RenameProfile(file File, name string) (string, error) {
+	dest, err := siblingProfile(file, name)
+	if err != nil {
+		return "", err
+	}
+	if err := checkSidecars(dest, file.Path); err != nil {
+		return "", err
+	}
+	if err := os.Rename(file.Path, dest); err != nil {
+		return "", err
+	}
+	for _, sidecar := range []func(string) string{MetadataPath, LeasePath} {
+		if err := os.Rename(sidecar(file.Path), sidecar(dest)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return dest, err
+		}
+	}
+	return dest, moveInstallState(file.Path, dest)
+}
+
+// DuplicateProfile copies the profile file, as stored on disk, and its
+// metadata to name inside the same directory. It returns the new path.
+func DuplicateProfile(file File, name string) (string, error) {
+	dest, err := siblingProfile(file, name)
+	if err != nil {
+		return "", err
+	}
+	if err := checkSidecars(dest, ""); err != nil {
+		return "", err
+	}
+	raw, err := os.ReadFile(file.Path)
+	if err != nil {
+		return "", err
+	}
+	if err := fsx.WriteFile(dest, raw, 0o600); err != nil {
+		return "", err
+	}
+	meta, err := os.ReadFile(MetadataPath(file.Path))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return dest, nil
+		}
+		return dest, err
+	}
+	return dest, fsx.WriteFile(MetadataPath(dest), meta, 0o600)
+}
+
+// TrashProfile moves the profile file and its metadata into the trash folder
+// of its directory, prefixed with a timestamp, and drops its lease. When the
+// profile is the installed one its install record moves into the trash too,
+// so nothing reports a profile that no longer exists. It returns the trashed
+// path.
+func TrashProfile(file File) (string, error) {
+	dir := filepath.Join(filepath.Dir(file.Path), trashDirName)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	stamp := time.Now().UTC().Format(backupStampLayout) + "-"
+	dest := filepath.Join(dir, stamp+filepath.Base(file.Path))
+	if err := os.Rename(file.Path, dest); err != nil {
+		return "", err
+	}
+	meta := MetadataPath(file.Path)
+	if err := os.Rename(meta, filepath.Join(dir, stamp+filepath.Base(meta))); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return dest, err
+	}
+	if err := os.Remove(LeasePath(file.Path)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return dest, err
+	}
+	return dest, stashInstallState(file.Path, dest+trashStateSuffix)
+}
+
+// FindTrashed returns the newest trashed profile in the trash folders below
+// roots whose file name or stem equals query.
+func FindTrashed(roots []string, query string) (string, error) {
+	var found, newest string
+	for _, root := range roots {
+		err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !entry.IsDir() || path == root {
+				return nil
+			}
+			if entry.Name() != trashDirName {
+				if strings.HasPrefix(entry.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			entries, err := os.ReadDir(path)
+			if err != nil {
+				return err
+			}
+			for _, trashed := range entries {
+				stamp, name, ok := splitTrashedName(trashed.Name())
+				if !ok || isReservedName(name) || strings.HasSuffix(name, trashStateSuffix) {
+					continue
+				}
+				if (name == query || ProfileStem(name) == query) && stamp > newest {
+					found, newest = filepath.Join(path, trashed.Name()), stamp
+				}
+			}
+			return filepath.SkipDir
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+	if found == "" {
+		return "", fmt.Errorf("%w %q in the trash", ErrNoMatch, query)
+	}
+	return found, nil
+}
+
+// RestoreTrashed moves a trashed profile and its metadata back into the
+// folder it was deleted from, refusing to replace a profile created there
+// since. When it was the installed profile and auth.json still holds its
+// account, the install record is restored as well. It returns the restored
+// path.
+func RestoreTrashed(trashed string) (string, error) {
+	stamp, name, ok := splitTrashedName(filepath.Base(trashed))
+	if !ok {
+		return "", fmt.Errorf("%s is not a trashed profile", trashed)
+	}
+	dir := filepath.Dir(trashed)
+	dest := filepath.Join(filepath.Dir(dir), name)
+	if _, err := os.Lstat(dest); err == nil {
+		return "", fmt.Errorf("%w: %s", ErrProfileExists, dest)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	if err := checkSidecars(dest, ""); err != nil {
+		return "", err
+	}
+	if err := os.Rename(trashed, dest); err != nil {
+		return "", err
+	}
+	meta := filepath.Base(MetadataPath(dest))
+	if err := os.Rename(filepath.Join(dir, stamp+"-"+meta), MetadataPath(dest)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return dest, err
+	}
+	return dest, unstashInstallState(trashed+trashStateSuffix, dest)
+}
+
+// splitTrashedName separates the timestamp TrashProfile prefixes from the
+// original file name.
+func splitTrashedName(base string) (string, string, bool) {
+	n := len(backupStampLayout)
+	if len(base) <= n+1 || base[n] != '-' {
+		return "", "", false
+	}
+	if _, err := time.Parse(backupStampLayout, base[:n]); err != nil {
+		return "", "", false
+	}
+	return base[:n], base[n+1:], true
+}
+
+// stashInstallState moves the install record to stash when it records source.
+func stashInstallState(source, stash string) error {
+	unlock, err := lockLive()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	state, ok, err := LoadInstallState()
+	if err != nil || !ok || filepath.Clean(state.Source) != filepath.Clean(source) {
+		return err
+	}
+	raw, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := fsx.WriteFile(stash, raw, 0o600); err != nil {
+		return err
+	}
+	return clearInstallState()
+}
+
+// unstashInstallState brings back an install record saved by
+// stashInstallState, pointing it at source. The record is dropped instead when
+// another profile has been installed since or auth.json no longer holds the
+// account it describes.
+func unstashInstallState(stash, source string) error {
+	raw, err := os.ReadFile(stash)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	var state InstallState
+	if err := json.Unmarshal(raw, &state); err != nil {
+		return err
+	}
+	unlock, err := lockLive()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	_, installed, err := LoadInstallState()
+	if err != nil {
+		return err
+	}
+	holds, err := liveHolds(state)
+	if err != nil {
+		return err
+	}
+	if !installed && holds {
+		state.Source = source
+		state.Profile = filepath.Base(source)
+		if err := saveInstallState(state); err != nil {
+			return err
+		}
+	}
+	return os.Remove(stash)
+}
+
+// liveHolds reports whether auth.json still holds what state installed,
+// allowing for tokens Codex refreshed since.
+func liveHolds(state InstallState) (bool, error) {
+	live, err := LiveAuthPath()
+	if err != nil {
+		return false, err
+	}
+	content, err := os.ReadFile(live)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	if digest(content) == state.Digest {
+		return true, nil
+	}
+	identity, _ := ParseIdentity(content)
+	return state.AccountID != "" && identity.AccountID == state.AccountID, nil
+}
+
+// siblingProfile returns the path of profile name next to file, refusing to
+// replace an existing profile.
+func siblingProfile(file File, name string) (string, error) {
+	fileName, err := ProfileFileName(name)
+	if err != nil {
+		return "", err
+	}
+	dest := filepath.Join(filepath.Dir(file.Path), fileName)
+	if _, err := os.Lstat(dest); err == nil {
+		return "", fmt.Errorf("%w: %s", ErrProfileExists, dest)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	return dest, nil
+}
+
+// moveInstallState points the install record at a renamed profile.
+func moveInstallState(from, to string) error {
+	unlock, err := lockLive()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	state, ok, err := LoadInstallState()
+	if err != nil || !ok || state.Source != from {
+		return err
+	}
+	state.Source = to
+	state.Profile = filepath.Base(to)
+	return saveInstallState(state)
+}
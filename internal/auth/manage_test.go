@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTrashInstalledProfile(t *testing.T) {
+	t.Setenv(CodexHomeEnv, t.TempDir())
+	root := t.TempDir()
+	path := filepath.Join(root, "work.auth.json")
+	content := []byte(`{"tokens":{"account_id":"work"}}`)
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(MetadataPath(path), []byte("label: Work\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	live, err := LiveAuthPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(live, content, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := recordInstall(path, content, content, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := TrashProfile(File{Path: path}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := LoadInstallState(); err != nil || ok {
+		t.Fatalf("install state still recorded after trashing (ok=%v, err=%v)", ok, err)
+	}
+
+	trashed, err := FindTrashed([]string{root}, "work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored, err := RestoreTrashed(trashed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored != path {
+		t.Errorf("restored to %s, want %s", restored, path)
+	}
+	if meta, err := os.ReadFile(MetadataPath(path)); err != nil || string(meta) != "label: Work\n" {
+		t.Errorf("metadata = %q, %v; want it restored", meta, err)
+	}
+	state, ok, err := LoadInstallState()
+	if err != nil || !ok || state.Source != path {
+		t.Errorf("install state = %+v (ok=%v, err=%v); want it restored", state, ok, err)
+	}
+	if _, err := FindTrashed([]string{root}, "work"); err == nil {
+		t.Error("profile still found in the trash after restoring it")
+	}
+}
+
+func TestRestoreTrashedKeepsNewerInstall(t *testing.T) {
+	t.Setenv(CodexHomeEnv, t.TempDir())
+	root := t.TempDir()
+	path := filepath.Join(root, "work.auth.json")
+	content := []byte(`{"tokens":{"account_id":"work"}}`)
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := recordInstall(path, content, content, false); err != nil {
+		t.Fatal(err)
+	}
+	trashed, err := TrashProfile(File{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := filepath.Join(root, "home.auth.json")
+	if err := recordInstall(other, content, content, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RestoreTrashed(trashed); err != nil {
+		t.Fatal(err)
+	}
+	state, ok, err := LoadInstallState()
+	if err != nil || !ok || state.Source != other {
+		t.Errorf("install state = %+v (ok=%v, err=%v); want %s kept", state, ok, err, other)
+	}
+	if _, err := os.Stat(trashed + trashStateSuffix); !os.IsNotExist(err) {
+		t.Errorf("stashed install record left behind: %v", err)
+	}
+}
+
+func TestSidecarConflicts(t *testing.T) {
+	t.Setenv(CodexHomeEnv, t.TempDir())
+	root := t.TempDir()
+	path := filepath.Join(root, "work.auth.json")
+	if err := os.WriteFile(path, []byte(`{"tokens":{"account_id":"work"}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DuplicateProfile(File{Path: path}, "work.json"); !errors.Is(err, ErrSidecarConflict) {
+		t.Errorf("DuplicateProfile to work.json = %v, want ErrSidecarConflict", err)
+	}
+	renamed, err := RenameProfile(File{Path: path}, "work.json")
+	if err != nil {
+		t.Fatalf("RenameProfile to work.json: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(`{"tokens":{"account_id":"other"}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	issues, err := Diagnose([]string{root})
+	if err != nil {
+		t.Fatal(err)
+	}
+	flagged := map[string]bool{}
+	for _, issue := range issues {
+		if issue.Kind == IssueSidecar {
+			flagged[issue.Path] = true
+		}
+	}
+	if !flagged[path] || !flagged[renamed] {
+		t.Errorf("sidecar issues = %v, want %s and %s", flagged, path, renamed)
+	}
+}
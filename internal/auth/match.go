@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNoMatch reports that no auth file matched a profile query.
+var ErrNoMatch = errors.New("no auth file matches")
+
+// AmbiguousMatchError lists the auth files that matched a query equally well.
+type AmbiguousMatchError struct {
+	Query      string
+	Candidates []File
+}
+
+func (e *AmbiguousMatchError) Error() string {
+	names := make([]string, len(e.Candidates))
+	for i, file := range e.Candidates {
+		names[i] = file.Name
+	}
+	return fmt.Sprintf("%q matches several auth files: %s", e.Query, strings.Join(names, ", "))
+}
+
+// Match resolves a profile query against the provided files. Exact names win,
+// followed by case-insensitive names, prefixes and finally fuzzy subsequences.
+// Names are compared with and without their .auth.json/.json suffix, and the
+// first two tiers also accept the bare file name of grouped profiles.
+func Match(files []File, query string) (File, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return File{}, fmt.Errorf("%w: empty profile name", ErrNoMatch)
+	}
+	lowered := strings.ToLower(query)
+	tiers := []func(File) bool{
+		func(f File) bool {
+			return f.Name == query || ProfileStem(f.Name) == query || f.Stem() == query
+		},
+		func(f File) bool {
+			return strings.EqualFold(f.Name, query) || strings.EqualFold(ProfileStem(f.Name), query) || strings.EqualFold(f.Stem(), query)
+		},
+		func(f File) bool {
+			return strings.HasPrefix(strings.ToLower(f.Name), lowered)
+		},
+		func(f File) bool {
+			return isSubsequence(lowered, strings.ToLower(f.Name))
+		},
+	}
+	for _, tier := range tiers {
+		var matches []File
+		for _, file := range files {
+			if tier(file) {
+				matches = append(matches, file)
+			}
+		}
+		switch len(matches) {
+		case 0:
+			continue
+		case 1:
+			return matches[0], nil
+		default:
+			return File{}, &AmbiguousMatchError{Query: query, Candidates: matches}
+		}
+	}
+	return File{}, fmt.Errorf("%w %q", ErrNoMatch, query)
+}
+
+// ProfileStem strips the conventional auth file suffixes from a file name.
+func ProfileStem(name string) string {
+	for _, suffix := range []string{profileSuffix, ".json"} {
+		if trimmed, ok := strings.CutSuffix(name, suffix); ok && trimmed != "" {
+			return trimmed
+		}
+	}
+	return name
+}
+
+func isSubsequence(needle, haystack string) bool {
+	if needle == "" {
+		return false
+	}
+	runes := []rune(needle)
+	idx := 0
+	for _, r := range haystack {
+		if r == runes[idx] {
+			idx++
+			if idx == len(runes) {
+				return true
+			}
+		}
+	}
+	return false
+}
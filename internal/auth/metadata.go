@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"codex-control/internal/fsx"
+)
+
+const metadataSuffix = ".meta.yaml"
+
+// Metadata holds optional user supplied details about a profile. It lives in
+// a <profile>.meta.yaml sidecar next to the auth file.
+type Metadata struct {
+	Label    string   `yaml:"label,omitempty" json:"label,omitempty"`
+	Notes    string   `yaml:"notes,omitempty" json:"notes,omitempty"`
+	Owner    string   `yaml:"owner,omitempty" json:"owner,omitempty"`
+	Tags     []string `yaml:"tags,omitempty" json:"tags,omitempty"`
+	Color    string   `yaml:"color,omitempty" json:"color,omitempty"`
+	Disabled bool     `yaml:"disabled,omitempty" json:"disabled,omitempty"`
+}
+
+// HasTags reports whether the metadata carries every tag in tags, ignoring case.
+func (m Metadata) HasTags(tags []string) bool {
+	for _, tag := range tags {
+		if !slices.ContainsFunc(m.Tags, func(t string) bool { return strings.EqualFold(t, tag) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// MetadataPath returns the sidecar location for the auth file at path.
+func MetadataPath(path string) string {
+	return filepath.Join(filepath.Dir(path), ProfileStem(filepath.Base(path))+metadataSuffix)
+}
+
+// LoadMetadata reads the sidecar of the auth file at path. A missing sidecar
+// yields empty metadata.
+func LoadMetadata(path string) (Metadata, error) {
+	raw, err := os.ReadFile(MetadataPath(path))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return Metadata{}, nil
+		}
+		return Metadata{}, err
+	}
+	var meta Metadata
+	if err := yaml.Unmarshal(raw, &meta); err != nil {
+		return Metadata{}, err
+	}
+	return meta, nil
+}
+
+// SaveMetadata writes the sidecar of the auth file at path.
+func SaveMetadata(path string, meta Metadata) error {
+	raw, err := yaml.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return fsx.WriteFile(MetadataPath(path), raw, 0o600)
+}
+
+// WriteMetadata validates raw as a metadata sidecar and stores it verbatim for
+// the auth file at path, keeping comments. Blank content removes the sidecar.
+func WriteMetadata(path string, raw []byte) error {
+	if len(bytes.TrimSpace(raw)) == 0 {
+		if err := os.Remove(MetadataPath(path)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(raw))
+	decoder.KnownFields(true)
+	var meta Metadata
+	if err := decoder.Decode(&meta); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("invalid metadata: %w", err)
+	}
+	return fsx.WriteFile(MetadataPath(path), raw, 0o600)
+}
+
+// FilterByTags keeps the files whose metadata carries every tag in tags.
+func FilterByTags(files []File, tags []string) []File {
+	if len(tags) == 0 {
+		return files
+	}
+	filtered := make([]File, 0, len(files))
+	for _, file := range files {
+		if file.Meta.HasTags(tags) {
+			filtered = append(filtered, file)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ProjectFileName is the dotfile that binds a directory tree to a profile.
+const ProjectFileName = ".codex-profile"
+
+// ProjectBinding is a profile reference found in a .codex-profile file.
+type ProjectBinding struct {
+	Path    string `json:"path"`
+	Profile string `json:"profile"`
+}
+
+// ErrBoundProfileNotFound reports that no profile matches a .codex-profile
+// binding exactly.
+var ErrBoundProfileNotFound = errors.New("bound profile not found")
+
+// Resolve returns the profile the binding names. Unlike Match it only accepts
+// exact matches: the profile's path (relative paths are taken from the folder
+// holding the .codex-profile), its listed name, or its stem. A stale or
+// renamed binding therefore fails instead of installing another account.
+func (b ProjectBinding) Resolve(files []File) (File, error) {
+	query := strings.TrimSpace(b.Profile)
+	path := query
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(b.Path), path)
+	}
+	path = filepath.Clean(path)
+	var matches []File
+	for _, file := range files {
+		if filepath.Clean(file.Path) == path || file.Name == query || ProfileStem(file.Name) == query || file.Stem() == query {
+			matches = append(matches, file)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return File{}, fmt.Errorf("%w: %q in %s", ErrBoundProfileNotFound, query, b.Path)
+	case 1:
+		return matches[0], nil
+	default:
+		return File{}, &AmbiguousMatchError{Query: query, Candidates: matches}
+	}
+}
+
+// FindProjectBinding walks up from dir to the filesystem root and returns the
+// nearest .codex-profile binding. The boolean is false when none exists. The
+// file holds the profile name on its first non-empty line; lines starting
+// with # are comments.
+func FindProjectBinding(dir string) (ProjectBinding, bool, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ProjectBinding{}, false, err
+	}
+	for {
+		path := filepath.Join(dir, ProjectFileName)
+		profile, err := readProjectFile(path)
+		if err == nil {
+			return ProjectBinding{Path: path, Profile: profile}, true, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return ProjectBinding{}, false, err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ProjectBinding{}, false, nil
+		}
+		dir = parent
+	}
+}
+
+func readProjectFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		return line, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", errors.New(path + " does not name a profile")
+}
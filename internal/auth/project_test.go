@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestProjectBindingResolve(t *testing.T) {
+	files := []File{
+		{Name: "work.auth.json", Path: "/auths/work.auth.json", Root: "/auths"},
+		{Name: "work-old.auth.json", Path: "/auths/work-old.auth.json", Root: "/auths"},
+		{Name: "team/ops.json", Path: "/auths/team/ops.json", Root: "/auths", Group: "team"},
+	}
+	tests := []struct {
+		name     string
+		profile  string
+		wantPath string
+		wantErr  error
+	}{
+		{name: "stem", profile: "work", wantPath: "/auths/work.auth.json"},
+		{name: "file name", profile: "work-old.auth.json", wantPath: "/auths/work-old.auth.json"},
+		{name: "grouped name", profile: "team/ops", wantPath: "/auths/team/ops.json"},
+		{name: "grouped stem", profile: "ops", wantPath: "/auths/team/ops.json"},
+		{name: "absolute path", profile: "/auths/team/ops.json", wantPath: "/auths/team/ops.json"},
+		{name: "relative path", profile: "../auths/work.auth.json", wantPath: "/auths/work.auth.json"},
+		{name: "case differs", profile: "Work", wantErr: ErrBoundProfileNotFound},
+		{name: "prefix only", profile: "wor", wantErr: ErrBoundProfileNotFound},
+		{name: "subsequence only", profile: "wkold", wantErr: ErrBoundProfileNotFound},
+		{name: "renamed profile", profile: "personal", wantErr: ErrBoundProfileNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			binding := ProjectBinding{Path: "/project/.codex-profile", Profile: tt.profile}
+			file, err := binding.Resolve(files)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Resolve error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve: %v", err)
+			}
+			if file.Path != tt.wantPath {
+				t.Errorf("Resolve = %s, want %s", file.Path, tt.wantPath)
+			}
+		})
+	}
+}
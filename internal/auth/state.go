@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"codex-control/internal/fsx"
+)
+
+const installStateFile = ".codex-auth-installed.json"
+
+// InstallState remembers which profile was last copied into the live auth.json.
+// Digest covers the plaintext copied into auth.json while SourceDigest covers
+// the profile bytes on disk, which differ for encrypted profiles. AccountID
+// and Email identify the installed credentials without decrypting the source.
+// APIProfile marks sources that describe an API key rather than tokens.
+type InstallState struct {
+	Profile      string    `json:"profile"`
+	Source       string    `json:"source"`
+	Digest       string    `json:"digest"`
+	SourceDigest string    `json:"source_digest,omitempty"`
+	AccountID    string    `json:"account_id,omitempty"`
+	Email        string    `json:"email,omitempty"`
+	APIProfile   bool      `json:"api_profile,omitempty"`
+	InstalledAt  time.Time `json:"installed_at"`
+}
+
+// LoadInstallState reads the record stored next to the live auth.json. The
+// boolean is false when no profile has been installed yet.
+func LoadInstallState() (InstallState, bool, error) {
+	path, err := installStatePath()
+	if err != nil {
+		return InstallState{}, false, err
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return InstallState{}, false, nil
+		}
+		return InstallState{}, false, err
+	}
+	var state InstallState
+	if err := json.Unmarshal(raw, &state); err != nil {
+		return InstallState{}, false, err
+	}
+	if state.SourceDigest == "" {
+		state.SourceDigest = state.Digest
+	}
+	return state, state.Source != "", nil
+}
+
+func saveInstallState(state InstallState) error {
+	path, err := installStatePath()
+	if err != nil {
+		return err
+	}
+	raw, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fsx.WriteFile(path, raw, 0o600)
+}
+
+// recordInstall stores the profile whose content now lives in auth.json. api
+// is set when the source is an API key profile.
+func recordInstall(source string, plain, raw []byte, api bool) error {
+	identity, _ := ParseIdentity(plain)
+	return saveInstallState(InstallState{
+		Profile:      filepath.Base(source),
+		Source:       source,
+		Digest:       digest(plain),
+		SourceDigest: digest(raw),
+		AccountID:    identity.AccountID,
+		Email:        identity.Email,
+		APIProfile:   api,
+		InstalledAt:  time.Now().UTC(),
+	})
+}
+
+// refreshInstallState updates the recorded source digest after the installed
+// profile was rewritten without changing its plaintext.
+func refreshInstallState(source string, raw []byte) error {
+	state, ok, err := LoadInstallState()
+	if err != nil || !ok || state.Source != source {
+		return err
+	}
+	state.SourceDigest = digest(raw)
+	return saveInstallState(state)
+}
+
+// forgetInstall clears the install state when it records source, for profiles
+// whose file was replaced so that auth.json no longer mirrors it.
+func forgetInstall(source string) error {
+	unlock, err := lockLive()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	state, ok, err := LoadInstallState()
+	if err != nil || !ok || filepath.Clean(state.Source) != filepath.Clean(source) {
+		return err
+	}
+	return clearInstallState()
+}
+
+func clearInstallState() error {
+	path, err := installStatePath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func installStatePath() (string, error) {
+	live, err := LiveAuthPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(live), installStateFile), nil
+}
+
+func digest(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// IsInstalled reports whether auth.json still holds exactly what was last
+// installed from source.
+func IsInstalled(source string) (bool, error) {
+	state, ok, err := LoadInstallState()
+	if err != nil || !ok || state.Source != source {
+		return false, err
+	}
+	live, err := LiveAuthPath()
+	if err != nil {
+		return false, err
+	}
+	content, err := os.ReadFile(live)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	return digest(content) == state.Digest, nil
+}
@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// SyncStatus describes the outcome of a sync attempt.
+type SyncStatus string
+
+const (
+	SyncUpdated   SyncStatus = "updated"
+	SyncInSync    SyncStatus = "in-sync"
+	SyncSkipped   SyncStatus = "skipped"
+	SyncConflict  SyncStatus = "conflict"
+	SyncUntracked SyncStatus = "untracked"
+)
+
+// SyncResult reports what happened to the installed profile during a sync.
+type SyncResult struct {
+	Profile       string     `json:"profile,omitempty"`
+	Source        string     `json:"source,omitempty"`
+	Live          string     `json:"live"`
+	Status        SyncStatus `json:"status"`
+	Detail        string     `json:"detail,omitempty"`
+	LiveRefresh   *time.Time `json:"live_refresh,omitempty"`
+	SourceRefresh *time.Time `json:"source_refresh,omitempty"`
+}
+
+// Sync copies the live auth.json back to the profile it was installed from
+// when Codex refreshed the tokens in the meantime. The source is only
+// overwritten when it is unchanged since the install, the live file holds the
+// same account and its refresh timestamp is newer. When both sides changed the
+// result reports a conflict and nothing is written. Encrypted profiles are
+// only decrypted when a write-back is actually considered.
+func Sync(opts InstallOptions) (SyncResult, error) {
+	live, err := LiveAuthPath()
+	if err != nil {
+		return SyncResult{}, err
+	}
+	result := SyncResult{Live: live, Status: SyncUntracked}
+	unlock, err := lockLive()
+	if err != nil {
+		return result, err
+	}
+	defer unlock()
+	state, ok, err := LoadInstallState()
+	if err != nil {
+		return result, err
+	}
+	if !ok {
+		result.Detail = "no profile has been installed by codex-auth"
+		return result, nil
+	}
+	result.Profile = state.Profile
+	result.Source = state.Source
+
+	liveContent, err := os.ReadFile(live)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			result.Detail = "live auth.json does not exist"
+			return result, nil
+		}
+		return result, err
+	}
+	sourceRaw, err := os.ReadFile(state.Source)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			result.Detail = "installed profile no longer exists"
+			return result, nil
+		}
+		return result, err
+	}
+
+	liveID, _ := ParseIdentity(liveContent)
+	result.LiveRefresh = optionalTime(liveID.LastRefresh)
+	liveChanged := digest(liveContent) != state.Digest
+	sourceChanged := digest(sourceRaw) != state.SourceDigest
+	encrypted := IsEncrypted(sourceRaw)
+	if !encrypted {
+		sourceID, _ := ParseIdentity(sourceRaw)
+		result.SourceRefresh = optionalTime(sourceID.LastRefresh)
+	}
+	switch {
+	case !encrypted && digest(liveContent) == digest(sourceRaw):
+		result.Status = SyncInSync
+		return result, nil
+	case !liveChanged && !sourceChanged:
+		result.Status = SyncInSync
+		return result, nil
+	case !liveChanged:
+		result.Status = SyncSkipped
+		result.Detail = "profile changed since install; live file is older"
+		return result, nil
+	case sourceChanged:
+		result.Status = SyncConflict
+		result.Detail = "both the live auth.json and the stored profile changed since install"
+		return result, nil
+	}
+
+	sourceContent, _, err := openProfile(sourceRaw, opts.Passphrase)
+	if err != nil {
+		return result, fmt.Errorf("%s: %w", state.Source, err)
+	}
+	if IsAPIProfile(sourceContent) {
+		result.Status = SyncSkipped
+		result.Detail = "API key profiles are never overwritten by the live auth.json"
+		return result, nil
+	}
+	sourceID, _ := ParseIdentity(sourceContent)
+	result.SourceRefresh = optionalTime(sourceID.LastRefresh)
+	if liveID.AccountID != "" && sourceID.AccountID != "" && liveID.AccountID != sourceID.AccountID {
+		result.Status = SyncConflict
+		result.Detail = fmt.Sprintf("live auth.json belongs to account %s, profile to %s", liveID.AccountID, sourceID.AccountID)
+		return result, nil
+	}
+	if !liveID.LastRefresh.After(sourceID.LastRefresh) {
+		result.Status = SyncSkipped
+		result.Detail = "live auth.json differs but its refresh timestamp is not newer"
+		return result, nil
+	}
+	if err := writeProfile(state.Source, liveContent, encrypted, opts.Passphrase); err != nil {
+		return result, err
+	}
+	written, err := os.ReadFile(state.Source)
+	if err != nil {
+		return result, err
+	}
+	if err := recordInstall(state.Source, liveContent, written, false); err != nil {
+		return result, err
+	}
+	result.Status = SyncUpdated
+	return result, nil
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -16,14 +16,20 @@ type UsageTracker struct {
 
 // LoadUsageTracker loads metadata stored inside the auth directory.
 func LoadUsageTracker(dir string) (*UsageTracker, error) {
-	tracker := &UsageTracker{
-		path: filepath.Join(dir, usageStateFile),
-		data: map[string]time.Time{},
+	path := filepath.Join(dir, usageStateFile)
+	data, err := readUsage(path)
+	if err != nil {
+		return nil, err
 	}
-	raw, err := os.ReadFile(tracker.path)
+	return &UsageTracker{path: path, data: data}, nil
+}
+
+func readUsage(path string) (map[string]time.Time, error) {
+	data := map[string]time.Time{}
+	raw, err := os.ReadFile(path)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
-			return tracker, nil
+			return data, nil
 		}
 		return nil, err
 	}
@@ -36,9 +42,20 @@ func LoadUsageTracker(dir string) (*UsageTracker, error) {
 		if err != nil {
 			continue
 		}
-		tracker.data[name] = ts
+		data[name] = ts
 	}
-	return tracker, nil
+	return data, nil
+}
+
+// Key returns the name the usage data and history of file are stored under:
+// the path relative to its root for profiles in the tracker's own folder,
+// "<root>:<relative path>" for profiles of other roots. Unlike File.Name it
+// does not change when another root gains a profile of the same name.
+func (t *UsageTracker) Key(file File) string {
+	if t == nil || file.Root == "" || filepath.Clean(file.Root) == filepath.Dir(t.path) {
+		return file.RelPath()
+	}
+	return filepath.Clean(file.Root) + ":" + file.RelPath()
 }
 
 // LastUsed returns the tracked timestamp for the given file.
@@ -54,10 +71,69 @@ func (t *UsageTracker) Touch(name string, ts time.Time) error {
 	if t == nil {
 		return nil
 	}
-	if t.data == nil {
-		t.data = map[string]time.Time{}
+	return t.update(func(data map[string]time.Time) {
+		data[name] = ts
+	})
+}
+
+// Rename moves the usage data and switch history of a renamed profile.
+func (t *UsageTracker) Rename(from, to string) error {
+	if t == nil {
+		return nil
+	}
+	err := t.update(func(data map[string]time.Time) {
+		if ts, ok := data[from]; ok {
+			data[to] = ts
+			delete(data, from)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return t.renameHistory(from, to)
+}
+
+// Merge adds imported last-used timestamps, keeping the newer one when a
+// profile is already tracked.
+func (t *UsageTracker) Merge(usage map[string]time.Time) error {
+	if t == nil || len(usage) == 0 {
+		return nil
+	}
+	return t.update(func(data map[string]time.Time) {
+		for name, ts := range usage {
+			if ts.After(data[name]) {
+				data[name] = ts
+			}
+		}
+	})
+}
+
+// Forget drops the last-used timestamp of a removed profile. Its switch
+// history is kept.
+func (t *UsageTracker) Forget(name string) error {
+	if t == nil {
+		return nil
+	}
+	return t.update(func(data map[string]time.Time) {
+		delete(data, name)
+	})
+}
+
+// update applies change to the usage data on disk while holding the auth
+// directory lock, so entries written by other processes since this tracker
+// was loaded are kept rather than overwritten.
+func (t *UsageTracker) update(change func(map[string]time.Time)) error {
+	unlock, err := lockDir(filepath.Dir(t.path))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	data, err := readUsage(t.path)
+	if err != nil {
+		return err
 	}
-	t.data[name] = ts
+	change(data)
+	t.data = data
 	return t.save()
 }
 
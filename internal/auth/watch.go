@@ -0,0 +1,24 @@
+package auth
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	"codex-control/internal/fsx"
+)
+
+// WatchRoots calls notify whenever profiles, metadata or leases change inside
+// roots, until ctx is done. Writes to the usage data, history and lock files
+// and temporary files are ignored, as are paths matching the ignore patterns;
+// ignored and hidden folders are not watched at all, like ListFiles skips them.
+func WatchRoots(ctx context.Context, roots []string, notify func()) error {
+	return fsx.Watch(ctx, roots, func(rel string) bool {
+		name := path.Base(rel)
+		switch name {
+		case usageStateFile, historyFile, lockFile, installStateFile:
+			return false
+		}
+		return !strings.HasPrefix(name, "codex-write-") && !strings.HasPrefix(name, "codex-auth-usage-") && !isIgnored(rel)
+	}, notify)
+}
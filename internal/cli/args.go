@@ -27,6 +27,45 @@ func Parse(fs *flag.FlagSet, args []string, aliases []FlagAlias) ([]string, erro
 	return fs.Args(), nil
 }
 
+// ParseInterspersed behaves like Parse but keeps parsing flags that appear
+// after positional arguments. Everything after "--" is returned verbatim.
+func ParseInterspersed(fs *flag.FlagSet, args []string, aliases []FlagAlias) ([]string, error) {
+	expanded, err := expandAliases(args, aliases)
+	if err != nil {
+		return nil, err
+	}
+	positional := []string{}
+	for {
+		if err := fs.Parse(expanded); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		consumed := len(expanded) - len(rest)
+		if consumed > 0 && expanded[consumed-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		positional = append(positional, rest[0])
+		expanded = rest[1:]
+	}
+}
+
+// CheckCommandFlags rejects flags that were set for a command they do not
+// apply to. owners maps each command-specific flag to the command accepting
+// it; flags missing from owners apply everywhere.
+func CheckCommandFlags(fs *flag.FlagSet, command string, owners map[string]string) error {
+	var err error
+	fs.Visit(func(f *flag.Flag) {
+		owner, ok := owners[f.Name]
+		if err == nil && ok && owner != command {
+			err = fmt.Errorf("--%s only applies to the %s command", f.Name, owner)
+		}
+	})
+	return err
+}
+
 func expandAliases(args []string, aliases []FlagAlias) ([]string, error) {
 	if len(aliases) == 0 {
 		return args, nil
@@ -99,10 +138,18 @@ type UsageOption struct {
 	Description string
 }
 
+// UsageCommand describes a subcommand in help output.
+type UsageCommand struct {
+	Name        string
+	Args        string
+	Description string
+}
+
 // UsagePrinter prints help text following the shared conventions.
 type UsagePrinter struct {
 	Command  string
 	Synopsis string
+	Commands []UsageCommand
 	Options  []UsageOption
 }
 
@@ -112,6 +159,17 @@ func (u UsagePrinter) Print() {
 		u.Command = "command"
 	}
 	fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s %s\n", u.Command, u.Synopsis)
+	if len(u.Commands) > 0 {
+		fmt.Fprintln(flag.CommandLine.Output(), "\nCommands:")
+		for _, cmd := range u.Commands {
+			label := cmd.Name
+			if cmd.Args != "" {
+				label = fmt.Sprintf("%s %s", label, cmd.Args)
+			}
+			fmt.Fprintf(flag.CommandLine.Output(), "  %s\n", label)
+			fmt.Fprintf(flag.CommandLine.Output(), "      %s\n", cmd.Description)
+		}
+	}
 	if len(u.Options) == 0 {
 		return
 	}
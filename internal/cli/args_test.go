@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"flag"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestParseInterspersed(t *testing.T) {
+	aliases := []FlagAlias{
+		{Canonical: "verbosity", Short: "v", HasValue: true},
+		{Canonical: "force", Short: "f"},
+	}
+	tests := []struct {
+		name           string
+		args           []string
+		wantPositional []string
+		wantVerbosity  int
+		wantForce      bool
+		wantErr        bool
+	}{
+		{name: "empty", args: nil, wantPositional: []string{}, wantVerbosity: 1},
+		{name: "flags first", args: []string{"--force", "use", "work"}, wantPositional: []string{"use", "work"}, wantVerbosity: 1, wantForce: true},
+		{name: "flags after command", args: []string{"use", "work", "-f", "-v", "2"}, wantPositional: []string{"use", "work"}, wantVerbosity: 2, wantForce: true},
+		{name: "flag between positionals", args: []string{"use", "--verbosity=0", "work"}, wantPositional: []string{"use", "work"}, wantVerbosity: 0},
+		{name: "long alias form", args: []string{"use", "--v", "3"}, wantPositional: []string{"use"}, wantVerbosity: 3},
+		{name: "double dash", args: []string{"use", "--", "-f", "--verbosity=2"}, wantPositional: []string{"use", "-f", "--verbosity=2"}, wantVerbosity: 1},
+		{name: "missing value", args: []string{"use", "-v"}, wantErr: true},
+		{name: "unknown flag", args: []string{"use", "--nope"}, wantErr: true},
+		{name: "bad value", args: []string{"-v", "many"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			verbosity := fs.Int("verbosity", 1, "")
+			force := fs.Bool("force", false, "")
+			positional, err := ParseInterspersed(fs, tt.args, aliases)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseInterspersed error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(positional, tt.wantPositional) {
+				t.Errorf("positional = %q, want %q", positional, tt.wantPositional)
+			}
+			if *verbosity != tt.wantVerbosity || *force != tt.wantForce {
+				t.Errorf("verbosity, force = %d, %v; want %d, %v", *verbosity, *force, tt.wantVerbosity, tt.wantForce)
+			}
+		})
+	}
+}
+
+func TestCheckCommandFlags(t *testing.T) {
+	owners := map[string]string{"fix": "doctor", "period": "stats"}
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr bool
+	}{
+		{name: "no flags", args: []string{"list"}},
+		{name: "shared flag", args: []string{"use", "-f"}},
+		{name: "owned flag on its command", args: []string{"doctor", "--fix"}},
+		{name: "owned flag with value", args: []string{"stats", "--period", "week"}},
+		{name: "owned flag elsewhere", args: []string{"use", "--fix"}, wantErr: true},
+		{name: "owned flag without command", args: []string{"--period=week"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			fs.Bool("force", false, "")
+			fs.Bool("fix", false, "")
+			fs.String("period", "day", "")
+			positional, err := ParseInterspersed(fs, tt.args, []FlagAlias{{Canonical: "force", Short: "f"}})
+			if err != nil {
+				t.Fatalf("ParseInterspersed: %v", err)
+			}
+			command := ""
+			if len(positional) > 0 {
+				command = positional[0]
+			}
+			err = CheckCommandFlags(fs, command, owners)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckCommandFlags error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
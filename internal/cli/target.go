@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"flag"
+	"path/filepath"
+
+	"codex-control/internal/codex"
+	"codex-control/internal/env"
+)
+
+// TargetConfig holds the install target settings shared by the Codex
+// updaters. Target is where the codex command goes; empty means ~/.local/bin
+// when writable, /usr/bin otherwise. Escalation is sudo, doas, pkexec or none
+// and only runs when the target folder is not writable.
+type TargetConfig struct {
+	Target     string `yaml:"target"`
+	Escalation string `yaml:"escalation"`
+}
+
+// DefaultTargetConfig returns the values written to new config files.
+func DefaultTargetConfig() TargetConfig {
+	return TargetConfig{Target: "", Escalation: string(codex.EscalateSudo)}
+}
+
+// Register binds --target and --escalation, defaulting to the loaded config.
+func (t *TargetConfig) Register(fs *flag.FlagSet) {
+	fs.StringVar(&t.Target, "target", t.Target, "Path the codex command is installed at.")
+	fs.StringVar(&t.Escalation, "escalation", t.Escalation, "Privilege escalation used for targets that are not writable.")
+}
+
+// Resolve returns the absolute target path and the escalation strategy.
+func (t TargetConfig) Resolve() (string, codex.Escalation, error) {
+	escalation, err := codex.ParseEscalation(t.Escalation)
+	if err != nil {
+		return "", "", err
+	}
+	target := t.Target
+	if target == "" {
+		target = env.DefaultTargetPath()
+	}
+	target, err = filepath.Abs(target)
+	if err != nil {
+		return "", "", err
+	}
+	return target, escalation, nil
+}
+
+// TargetUsageOptions returns the help entries of the target flags.
+func TargetUsageOptions() []UsageOption {
+	return []UsageOption{
+		{
+			Long:        "target",
+			Short:       "t",
+			Value:       "<path>",
+			Description: "Install the codex command at this path (default ~/.local/bin/codex when writable, /usr/bin/codex otherwise).",
+		},
+		{
+			Long:        "escalation",
+			Short:       "e",
+			Value:       "<sudo|doas|pkexec|none>",
+			Description: "Tool used to install into folders the current user cannot write to (default sudo).",
+		},
+	}
+}
+
+// TargetAliases returns the short forms of the target flags.
+func TargetAliases() []FlagAlias {
+	return []FlagAlias{
+		{Canonical: "target", Short: "t", HasValue: true},
+		{Canonical: "escalation", Short: "e", HasValue: true},
+	}
+}
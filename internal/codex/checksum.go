@@ -0,0 +1,172 @@
+package codex
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"codex-control/internal/logger"
+)
+
+// maxChecksumSize caps how much of a checksum asset is read.
+const maxChecksumSize = 1 << 20
+
+// checksumAssetNames are release assets listing the digests of every archive.
+var checksumAssetNames = []string{"SHA256SUMS", "SHA256SUMS.txt", "sha256sums.txt", "checksums.txt"}
+
+var (
+	// ErrChecksumMismatch reports an archive whose digest differs from an
+	// expected one.
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+	// ErrNoChecksum reports that no expected digest exists for an archive.
+	ErrNoChecksum = errors.New("no checksum available")
+)
+
+// expectedDigest is a SHA-256 digest an archive must match and where it came
+// from.
+type expectedDigest struct {
+	source string
+	sum    string
+}
+
+// PinnedKey returns the key of a pinned digest for asset in release, as used
+// in the pinned-digests config.
+func PinnedKey(release Release, asset Asset) string {
+	return release.Tag + "/" + asset.Name
+}
+
+// expectedDigests collects the digests asset must match: the pinned value, the
+// digest GitHub reports for the asset and the release checksum asset.
+func (i *Installer) expectedDigests(ctx context.Context, release Release, asset Asset) ([]expectedDigest, error) {
+	var expected []expectedDigest
+	if pinned, ok := i.PinnedDigests[PinnedKey(release, asset)]; ok {
+		sum, err := normalizeDigest(pinned)
+		if err != nil {
+			return nil, fmt.Errorf("pinned digest for %s: %w", PinnedKey(release, asset), err)
+		}
+		expected = append(expected, expectedDigest{source: "pinned", sum: sum})
+	}
+	if asset.Digest != "" {
+		sum, err := normalizeDigest(asset.Digest)
+		if err != nil {
+			return nil, fmt.Errorf("GitHub digest of %s: %w", asset.Name, err)
+		}
+		expected = append(expected, expectedDigest{source: "github", sum: sum})
+	}
+	checksums, ok := findChecksumAsset(release, asset)
+	if ok {
+		raw, err := i.fetch(ctx, checksums.URL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch %s: %w", checksums.Name, err)
+		}
+		sum, found := parseChecksums(raw, asset.Name)
+		if !found {
+			return nil, fmt.Errorf("%s does not list %s", checksums.Name, asset.Name)
+		}
+		expected = append(expected, expectedDigest{source: checksums.Name, sum: sum})
+	}
+	return expected, nil
+}
+
+// verify compares the downloaded archive digest with every expected digest
+// and returns the sources that matched. It fails when one differs. Releases
+// without any digest, such as older ones, only log a warning unless
+// RequireChecksum is set.
+func (i *Installer) verify(ctx context.Context, release Release, asset Asset, sum string) ([]string, error) {
+	expected, err := i.expectedDigests(ctx, release, asset)
+	if err != nil {
+		return nil, err
+	}
+	if len(expected) == 0 {
+		if i.RequireChecksum {
+			return nil, fmt.Errorf("%w for %s in %s", ErrNoChecksum, asset.Name, release.Tag)
+		}
+		if i.Log != nil {
+			i.Log.Printf(logger.PrefixInstall, "Warning: %s publishes no checksum for %s; installing it unverified", release.Tag, asset.Name)
+		}
+		return nil, nil
+	}
+	sources := make([]string, 0, len(expected))
+	for _, want := range expected {
+		if want.sum != sum {
+			return nil, fmt.Errorf("%w for %s: %s digest is sha256:%s, download is sha256:%s", ErrChecksumMismatch, asset.Name, want.source, want.sum, sum)
+		}
+		sources = append(sources, want.source)
+	}
+	return sources, nil
+}
+
+// findChecksumAsset looks for <archive>.sha256 and then a combined checksum
+// list in the release.
+func findChecksumAsset(release Release, asset Asset) (Asset, bool) {
+	if found, ok := release.FindAsset(asset.Name + ".sha256"); ok {
+		return found, true
+	}
+	for _, name := range checksumAssetNames {
+		if found, ok := release.FindAsset(name); ok {
+			return found, true
+		}
+	}
+	return Asset{}, false
+}
+
+// parseChecksums finds the digest of name in sha256sum or BSD style output. A
+// file holding a single bare digest applies to any name.
+func parseChecksums(raw []byte, name string) (string, bool) {
+	lines := strings.Split(strings.TrimSpace(string(raw)), "\n")
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 1 && len(lines) == 1:
+			if sum, err := normalizeDigest(fields[0]); err == nil {
+				return sum, true
+			}
+		case len(fields) == 2:
+			if path.Base(strings.TrimPrefix(fields[1], "*")) != name {
+				continue
+			}
+			if sum, err := normalizeDigest(fields[0]); err == nil {
+				return sum, true
+			}
+		case len(fields) == 4 && fields[0] == "SHA256" && fields[2] == "=":
+			if path.Base(strings.Trim(fields[1], "()")) != name {
+				continue
+			}
+			if sum, err := normalizeDigest(fields[3]); err == nil {
+				return sum, true
+			}
+		}
+	}
+	return "", false
+}
+
+// normalizeDigest accepts a hex SHA-256 digest with an optional "sha256:"
+// prefix and returns it in lower case.
+func normalizeDigest(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if algo, sum, ok := strings.Cut(value, ":"); ok {
+		if !strings.EqualFold(algo, "sha256") {
+			return "", fmt.Errorf("unsupported digest algorithm %q", algo)
+		}
+		value = sum
+	}
+	value = strings.ToLower(value)
+	if decoded, err := hex.DecodeString(value); err != nil || len(decoded) != 32 {
+		return "", fmt.Errorf("malformed SHA-256 digest %q", value)
+	}
+	return value, nil
+}
+
+// fetch downloads a small asset such as a checksum list into memory.
+func (i *Installer) fetch(ctx context.Context, url string) ([]byte, error) {
+	body, err := i.open(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return io.ReadAll(io.LimitReader(body, maxChecksumSize))
+}
@@ -0,0 +1,139 @@
+package codex
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+const (
+	testSum      = "1ab7a214ced7446518d6d4bb4fdde90e31e74f5afd47fcdc5cbda7211f9fcdee"
+	testOtherSum = "6e020e77156ba1b40458e62706841fea34b4e5c97e06285bf3c2446857e0f706"
+	testArchive  = "codex-x86_64-unknown-linux-musl.tar.gz"
+)
+
+func TestParseChecksums(t *testing.T) {
+	tests := []struct {
+		name   string
+		raw    string
+		want   string
+		wantOK bool
+	}{
+		{name: "sha256sum", raw: testOtherSum + "  other.tar.gz\n" + testSum + "  " + testArchive + "\n", want: testSum, wantOK: true},
+		{name: "binary marker", raw: testSum + " *" + testArchive, want: testSum, wantOK: true},
+		{name: "path prefix", raw: testSum + "  dist/" + testArchive, want: testSum, wantOK: true},
+		{name: "upper case", raw: "1AB7A214CED7446518D6D4BB4FDDE90E31E74F5AFD47FCDC5CBDA7211F9FCDEE  " + testArchive, want: testSum, wantOK: true},
+		{name: "bsd style", raw: "SHA256 (" + testArchive + ") = " + testSum, want: testSum, wantOK: true},
+		{name: "bare digest", raw: testSum + "\n", want: testSum, wantOK: true},
+		{name: "prefixed bare digest", raw: "sha256:" + testSum, want: testSum, wantOK: true},
+		{name: "crlf", raw: testSum + "  " + testArchive + "\r\n", want: testSum, wantOK: true},
+		{name: "other archive only", raw: testSum + "  other.tar.gz\n" + testOtherSum + "  another.tar.gz"},
+		{name: "similar name", raw: testSum + "  " + testArchive + ".sig"},
+		{name: "bare digest among several lines", raw: testSum + "\n" + testOtherSum},
+		{name: "malformed digest", raw: "deadbeef  " + testArchive},
+		{name: "bsd other algorithm", raw: "MD5 (" + testArchive + ") = " + testSum},
+		{name: "empty", raw: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseChecksums([]byte(tt.raw), testArchive)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("parseChecksums = %q, %v; want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestNormalizeDigest(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: testSum, want: testSum},
+		{value: " sha256:" + testSum + " ", want: testSum},
+		{value: "SHA256:1AB7A214CED7446518D6D4BB4FDDE90E31E74F5AFD47FCDC5CBDA7211F9FCDEE", want: testSum},
+		{value: "sha512:" + testSum, wantErr: true},
+		{value: testSum[:62], wantErr: true},
+		{value: "zz" + testSum[2:], wantErr: true},
+		{value: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := normalizeDigest(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("normalizeDigest(%q) = %q, %v; want %q, error %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/SHA256SUMS":
+			w.Write([]byte(testSum + "  " + testArchive + "\n"))
+		case "/unlisted/SHA256SUMS":
+			w.Write([]byte(testSum + "  other.tar.gz\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	archive := Asset{Name: testArchive}
+	withDigest := Asset{Name: testArchive, Digest: "sha256:" + testSum}
+	checksums := Asset{Name: "SHA256SUMS", URL: server.URL + "/SHA256SUMS"}
+	unlisted := Asset{Name: "SHA256SUMS", URL: server.URL + "/unlisted/SHA256SUMS"}
+	missing := Asset{Name: testArchive + ".sha256", URL: server.URL + "/missing"}
+	pinned := map[string]string{"rust-v1.0.0/" + testArchive: testSum}
+	pinnedOther := map[string]string{"rust-v1.0.0/" + testArchive: testOtherSum}
+
+	tests := []struct {
+		name            string
+		assets          []Asset
+		asset           Asset
+		pinned          map[string]string
+		requireChecksum bool
+		want            []string
+		wantErr         error
+		wantAnyErr      bool
+	}{
+		{name: "no digest", assets: []Asset{archive}, asset: archive},
+		{name: "no digest required", assets: []Asset{archive}, asset: archive, requireChecksum: true, wantErr: ErrNoChecksum},
+		{name: "github digest", assets: []Asset{withDigest}, asset: withDigest, want: []string{"github"}},
+		{name: "every source", assets: []Asset{withDigest, checksums}, asset: withDigest, pinned: pinned, want: []string{"pinned", "github", "SHA256SUMS"}},
+		{name: "pinned mismatch", assets: []Asset{withDigest}, asset: withDigest, pinned: pinnedOther, wantErr: ErrChecksumMismatch},
+		{name: "pinned mismatch without other digests", assets: []Asset{archive}, asset: archive, pinned: pinnedOther, wantErr: ErrChecksumMismatch},
+		{name: "checksum file does not list archive", assets: []Asset{archive, unlisted}, asset: archive, wantAnyErr: true},
+		{name: "checksum asset unavailable", assets: []Asset{archive, missing}, asset: archive, wantAnyErr: true},
+		{name: "malformed github digest", assets: []Asset{{Name: testArchive, Digest: "md5:abc"}}, asset: Asset{Name: testArchive, Digest: "md5:abc"}, wantAnyErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installer := Installer{Client: NewClient(server.Client(), ""), PinnedDigests: tt.pinned, RequireChecksum: tt.requireChecksum}
+			release := Release{Tag: "rust-v1.0.0", Assets: tt.assets}
+			got, err := installer.verify(t.Context(), release, tt.asset, testSum)
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("verify error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			case tt.wantAnyErr:
+				if err == nil {
+					t.Fatal("verify succeeded, want an error")
+				}
+				return
+			case err != nil:
+				t.Fatalf("verify: %v", err)
+			}
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("verify sources = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
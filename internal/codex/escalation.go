@@ -0,0 +1,85 @@
+package codex
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"codex-control/internal/fsx"
+	"codex-control/internal/logger"
+)
+
+// Escalation names the tool used to install into targets the current user
+// cannot write to.
+type Escalation string
+
+// Supported escalation strategies. EscalateNone never runs commands as
+// another user and fails on targets that are not writable.
+const (
+	EscalateSudo   Escalation = "sudo"
+	EscalateDoas   Escalation = "doas"
+	EscalatePkexec Escalation = "pkexec"
+	EscalateNone   Escalation = "none"
+)
+
+// ParseEscalation validates a configured escalation strategy; empty means
+// sudo.
+func ParseEscalation(value string) (Escalation, error) {
+	switch strategy := Escalation(strings.ToLower(strings.TrimSpace(value))); strategy {
+	case "":
+		return EscalateSudo, nil
+	case EscalateSudo, EscalateDoas, EscalatePkexec, EscalateNone:
+		return strategy, nil
+	}
+	return "", fmt.Errorf("unknown escalation %q (want sudo, doas, pkexec or none)", value)
+}
+
+// link points target at path. The link is swapped in place when the target
+// folder is writable. Folders the user cannot write to belong to someone
+// else, usually root, so they get a root-owned copy installed through the
+// escalation tool instead: a link there would let anything running as the
+// user replace the binary other users run.
+func (i *Installer) link(path, target string) error {
+	dir := filepath.Dir(target)
+	if fsx.Writable(dir) {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+		tmp := filepath.Join(dir, fmt.Sprintf(".%s.%d", filepath.Base(target), os.Getpid()))
+		os.Remove(tmp)
+		if err := os.Symlink(path, tmp); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp, target); err != nil {
+			os.Remove(tmp)
+			return err
+		}
+		return nil
+	}
+	escalation := i.Escalation
+	if escalation == "" {
+		escalation = EscalateSudo
+	}
+	if escalation == EscalateNone {
+		return fmt.Errorf("%s is not writable and escalation is disabled; choose a writable target", dir)
+	}
+	if _, err := exec.LookPath(string(escalation)); err != nil {
+		return fmt.Errorf("%s is not writable and %s is not available: %w", dir, escalation, err)
+	}
+	if i.Log != nil {
+		i.Log.Printf(logger.PrefixInstall, "%s is not writable; using %s", dir, escalation)
+	}
+	// A single escalated shell keeps it to one password prompt and renames
+	// the copy over the target so it never points into the user's store.
+	tmp := filepath.Join(dir, "."+filepath.Base(target)+".new")
+	cmd := exec.Command(string(escalation), "sh", "-c", `mkdir -p "$1" && install -m 0755 "$2" "$3" && mv -f "$3" "$4"`, "sh", dir, path, tmp, target)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s install failed: %w", escalation, err)
+	}
+	return nil
+}
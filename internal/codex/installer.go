@@ -4,6 +4,8 @@ import (
 	"archive/tar"
 	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
@@ -12,26 +14,66 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"codex-control/internal/logger"
 )
 
-// Installer downloads and installs Codex binaries.
+// Installer downloads Codex binaries into the version store and points the
+// target path at the active one.
 type Installer struct {
 	Client     *Client
 	Log        *logger.Logger
 	Workdir    string
 	TargetPath string
+	// Escalation runs the link step when TargetPath's folder is not
+	// writable; empty means sudo.
+	Escalation Escalation
+	Store      Store
+	// KeepVersions is how many stored versions survive an install; below one
+	// keeps them all.
+	KeepVersions int
+	// Force makes InstallLatest reinstall even when the target already runs
+	// the latest release.
+	Force bool
+	// PinnedDigests maps "<tag>/<archive>" to the SHA-256 digest the archive
+	// must have.
+	PinnedDigests map[string]string
+	// RequireChecksum refuses archives no digest is known for instead of
+	// installing them with a warning.
+	RequireChecksum bool
+	// SignaturePolicy decides whether a detached release signature must
+	// validate against one of SignatureKeys.
+	SignaturePolicy SignaturePolicy
+	SignatureKeys   []SignatureKey
 }
 
-// InstallResult summarizes an installation run.
+// InstallResult summarizes an installation run. Digest is the SHA-256 of the
+// downloaded archive and VerifiedBy lists the sources it was checked against.
+// SignedBy names the key that validated the release signature. Binary is the
+// stored copy the target links to and Pruned lists versions removed by the
+// retention policy. Status is set when nothing had to be installed.
+// ShadowedBy names another codex that comes before the target on PATH and
+// therefore runs instead of it.
 type InstallResult struct {
-	Version string `json:"version"`
-	Target  string `json:"target"`
-	Archive string `json:"archive"`
-	Bytes   int64  `json:"bytes"`
+	Version    string   `json:"version"`
+	Target     string   `json:"target"`
+	Binary     string   `json:"binary"`
+	Archive    string   `json:"archive"`
+	Bytes      int64    `json:"bytes,omitempty"`
+	Digest     string   `json:"digest"`
+	VerifiedBy []string `json:"verified_by,omitempty"`
+	SignedBy   string   `json:"signed_by,omitempty"`
+	Pruned     []string `json:"pruned,omitempty"`
+	// Installed is the version the target ran before the update.
+	Installed  string `json:"installed,omitempty"`
+	Status     string `json:"status,omitempty"`
+	ShadowedBy string `json:"shadowed_by,omitempty"`
 }
 
+// statusUpToDate marks results of updates that found nothing newer.
+const statusUpToDate = "already up to date"
+
 // InstallLatest fetches the newest release and installs it.
 func (i *Installer) InstallLatest(ctx context.Context, platform Platform) (InstallResult, error) {
 	if err := i.validate(); err != nil {
@@ -46,7 +88,54 @@ func (i *Installer) InstallLatest(ctx context.Context, platform Platform) (Insta
 	if !ok {
 		return InstallResult{}, fmt.Errorf("asset %s not found in release %s", archive, release.Tag)
 	}
-	return i.install(ctx, release, asset)
+	installed, found := i.installedVersion(ctx)
+	if !i.Force && found {
+		current, okCurrent := ParseVersion(installed)
+		latest, okLatest := ParseVersion(release.Tag)
+		if okCurrent && okLatest && current.Compare(latest) >= 0 {
+			if i.Log != nil {
+				i.Log.Printf(logger.PrefixInstall, "Codex %s is already up to date (latest release %s)", current, release.Tag)
+			}
+			binary, err := filepath.EvalSymlinks(i.TargetPath)
+			if err != nil {
+				binary = i.TargetPath
+			}
+			result := InstallResult{Version: release.Tag, Target: i.TargetPath, Binary: binary, Archive: asset.Name, Installed: installed, Status: statusUpToDate}
+			if stored, ok, _ := i.Store.Get(installed); ok {
+				result.Digest = stored.Digest
+				result.SignedBy = stored.SignedBy
+			}
+			result.ShadowedBy = i.shadowedBy()
+			return result, nil
+		}
+	}
+	result, err := i.install(ctx, release, asset)
+	result.Installed = installed
+	return result, err
+}
+
+// installedVersion reports what the target currently runs: the store's
+// current tag when the target links to it, otherwise the output of
+// codex --version. The boolean is false when the target cannot be run.
+func (i *Installer) installedVersion(ctx context.Context) (string, bool) {
+	resolved, err := filepath.EvalSymlinks(i.TargetPath)
+	if err != nil {
+		return "", false
+	}
+	if current, _, err := i.Store.Current(); err == nil && current != "" {
+		if version, ok, err := i.Store.Get(current); err == nil && ok {
+			if stored, err := filepath.EvalSymlinks(version.Path); err == nil && stored == resolved {
+				return current, true
+			}
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, i.TargetPath, "--version").Output()
+	if err != nil {
+		return "", false
+	}
+	return strings.TrimSpace(string(out)), true
 }
 
 // InstallRelease installs a specific release + asset pair.
@@ -66,7 +155,24 @@ func (i *Installer) install(ctx context.Context, release Release, asset Asset) (
 		return InstallResult{}, err
 	}
 	defer os.Remove(archiveFile.Name())
-	if err := i.download(ctx, asset.URL, archiveFile); err != nil {
+	sum, err := i.download(ctx, asset.URL, archiveFile)
+	archiveFile.Close()
+	if err != nil {
+		return InstallResult{}, err
+	}
+	verifiedBy, err := i.verify(ctx, release, asset, sum)
+	if err != nil {
+		return InstallResult{}, err
+	}
+	if i.Log != nil {
+		if len(verifiedBy) == 0 {
+			i.Log.Printf(logger.PrefixDownload, "No checksum known for %s; installing unverified sha256:%s", asset.Name, sum)
+		} else {
+			i.Log.Printf(logger.PrefixDownload, "Verified sha256:%s against %s", sum, strings.Join(verifiedBy, ", "))
+		}
+	}
+	signedBy, err := i.verifySignature(ctx, release, asset, archiveFile.Name())
+	if err != nil {
 		return InstallResult{}, err
 	}
 	if i.Log != nil {
@@ -78,20 +184,104 @@ func (i *Installer) install(ctx context.Context, release Release, asset Asset) (
 	}
 	defer os.Remove(binaryPath)
 
-	target := i.TargetPath
-	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+	version, err := i.Store.Add(binaryPath, InstalledVersion{
+		Version:     release.Tag,
+		Archive:     asset.Name,
+		Digest:      "sha256:" + sum,
+		SignedBy:    signedBy,
+		InstalledAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return InstallResult{}, fmt.Errorf("failed to store %s: %w", release.Tag, err)
+	}
+	result, err := i.activate(version)
+	if err != nil {
+		return InstallResult{}, err
+	}
+	result.Bytes = asset.Size
+	result.VerifiedBy = verifiedBy
+	result.Pruned, err = i.Store.Prune(i.KeepVersions)
+	if err != nil && i.Log != nil {
+		i.Log.Errorf(logger.PrefixInstall, "Failed to prune old versions: %v", err)
+	}
+	return result, nil
+}
+
+// Use points the target at the stored version tag without downloading it.
+func (i *Installer) Use(tag string) (InstallResult, error) {
+	version, ok, err := i.Store.Get(tag)
+	if err != nil {
+		return InstallResult{}, err
+	}
+	if !ok {
+		return InstallResult{}, fmt.Errorf("version %s is not installed; see codex-update list-installed", tag)
+	}
+	return i.activate(version)
+}
+
+// Rollback switches the target back to the version that was active before
+// the current one.
+func (i *Installer) Rollback() (InstallResult, error) {
+	_, previous, err := i.Store.Current()
+	if err != nil {
 		return InstallResult{}, err
 	}
+	if previous == "" {
+		return InstallResult{}, errors.New("no previous version to roll back to")
+	}
+	return i.Use(previous)
+}
+
+// activate links the target to the stored version and records it as current.
+func (i *Installer) activate(version InstalledVersion) (InstallResult, error) {
+	target := i.TargetPath
+	if target == "" {
+		return InstallResult{}, errors.New("installer target path is empty")
+	}
 	if i.Log != nil {
-		i.Log.Printf(logger.PrefixInstall, "Installing Codex to %s", target)
+		i.Log.Printf(logger.PrefixInstall, "Linking %s to Codex %s", target, version.Version)
 	}
-	cmd := exec.Command("sudo", "install", "-m", "0755", binaryPath, target)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
+	if err := i.link(version.Path, target); err != nil {
 		return InstallResult{}, err
 	}
-	return InstallResult{Version: release.Tag, Target: target, Archive: asset.Name, Bytes: asset.Size}, nil
+	if err := i.Store.SetCurrent(version.Version); err != nil {
+		return InstallResult{}, err
+	}
+	return InstallResult{
+		Version:    version.Version,
+		Target:     target,
+		Binary:     version.Path,
+		Archive:    version.Archive,
+		Digest:     version.Digest,
+		SignedBy:   version.SignedBy,
+		ShadowedBy: i.shadowedBy(),
+	}, nil
+}
+
+// shadowedBy returns the codex found on PATH when it is not the target, for
+// example an old /usr/bin/codex left behind after moving to ~/.local/bin.
+// Both cases are logged as warnings since the shell would not run the
+// installed version.
+func (i *Installer) shadowedBy() string {
+	name := filepath.Base(i.TargetPath)
+	found, err := exec.LookPath(name)
+	if err != nil {
+		if i.Log != nil {
+			i.Log.Printf(logger.PrefixInstall, "Warning: %s is not on PATH; add it to run %s", filepath.Dir(i.TargetPath), name)
+		}
+		return ""
+	}
+	foundInfo, err := os.Stat(found)
+	if err != nil {
+		return ""
+	}
+	if targetInfo, err := os.Stat(i.TargetPath); err == nil && os.SameFile(foundInfo, targetInfo) {
+		return ""
+	}
+	if i.Log != nil {
+		i.Log.Printf(logger.PrefixInstall, "Warning: %s comes first on PATH and runs instead of %s", found, i.TargetPath)
+	}
+	return found
 }
 
 func (i *Installer) validate() error {
@@ -104,6 +294,9 @@ func (i *Installer) validate() error {
 	if i.TargetPath == "" {
 		return errors.New("installer target path is empty")
 	}
+	if i.Store.Dir == "" {
+		return errors.New("version store path is empty")
+	}
 	return nil
 }
 
@@ -114,10 +307,28 @@ func (i *Installer) createTempFile(pattern string) (*os.File, error) {
 	return os.CreateTemp(i.Workdir, pattern)
 }
 
-func (i *Installer) download(ctx context.Context, url string, dest *os.File) error {
+// download writes the asset at url into dest and returns its hex SHA-256.
+func (i *Installer) download(ctx context.Context, url string, dest *os.File) (string, error) {
+	body, err := i.open(ctx, url)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(dest, hash), body); err != nil {
+		return "", err
+	}
+	if err := dest.Sync(); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// open starts a download of url and returns the response body.
+func (i *Installer) open(ctx context.Context, url string) (io.ReadCloser, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	req.Header.Set("User-Agent", userAgent)
 	if i.Client != nil {
@@ -125,22 +336,13 @@ func (i *Installer) download(ctx context.Context, url string, dest *os.File) err
 	}
 	resp, err := i.Client.httpClient.Do(req)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("download failed: %s", resp.Status)
-	}
-	if _, err := io.Copy(dest, resp.Body); err != nil {
-		return err
+		resp.Body.Close()
+		return nil, fmt.Errorf("download failed: %s", resp.Status)
 	}
-	if err := dest.Sync(); err != nil {
-		return err
-	}
-	if _, err := dest.Seek(0, io.SeekStart); err != nil {
-		return err
-	}
-	return nil
+	return resp.Body, nil
 }
 
 func extractBinary(archivePath, destDir string) (string, error) {
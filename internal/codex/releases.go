@@ -22,11 +22,13 @@ type Release struct {
 	Assets      []Asset
 }
 
-// Asset represents an artifact tied to a release.
+// Asset represents an artifact tied to a release. Digest is the
+// "sha256:<hex>" value GitHub reports for the file, when available.
 type Asset struct {
-	Name string
-	URL  string
-	Size int64
+	Name   string
+	URL    string
+	Size   int64
+	Digest string
 }
 
 // Client fetches Codex release metadata from GitHub.
@@ -132,15 +134,16 @@ type releasePayload struct {
 }
 
 type assetPayload struct {
-	Name string `json:"name"`
-	URL  string `json:"browser_download_url"`
-	Size int64  `json:"size"`
+	Name   string `json:"name"`
+	URL    string `json:"browser_download_url"`
+	Size   int64  `json:"size"`
+	Digest string `json:"digest"`
 }
 
 func (r releasePayload) toRelease() Release {
 	assets := make([]Asset, 0, len(r.Assets))
 	for _, asset := range r.Assets {
-		assets = append(assets, Asset{Name: asset.Name, URL: asset.URL, Size: asset.Size})
+		assets = append(assets, Asset{Name: asset.Name, URL: asset.URL, Size: asset.Size, Digest: asset.Digest})
 	}
 	return Release{Tag: r.TagName, PublishedAt: parseTime(r.PublishedAt), Assets: assets}
 }
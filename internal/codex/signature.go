@@ -0,0 +1,241 @@
+package codex
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+
+	"codex-control/internal/logger"
+)
+
+// SignaturePolicy decides what happens when a release signature is missing
+// or does not verify.
+type SignaturePolicy string
+
+const (
+	SignatureOff     SignaturePolicy = "off"
+	SignatureWarn    SignaturePolicy = "warn"
+	SignatureRequire SignaturePolicy = "require"
+)
+
+// ParseSignaturePolicy validates a signature-policy setting. Empty means off.
+func ParseSignaturePolicy(value string) (SignaturePolicy, error) {
+	switch policy := SignaturePolicy(strings.ToLower(strings.TrimSpace(value))); policy {
+	case "":
+		return SignatureOff, nil
+	case SignatureOff, SignatureWarn, SignatureRequire:
+		return policy, nil
+	}
+	return "", fmt.Errorf("invalid signature policy %q (want off, warn or require)", value)
+}
+
+var (
+	// ErrNoSignature reports a release without a signature for the archive.
+	ErrNoSignature = errors.New("no signature found")
+	// ErrBadSignature reports a signature no configured key validates.
+	ErrBadSignature = errors.New("signature does not verify")
+)
+
+// SignatureKey is a trusted public key. Minisign holds a minisign public key
+// (the base64 line of minisign.pub); PublicKey holds a PEM encoded ECDSA,
+// Ed25519 or RSA key as used by cosign sign-blob.
+type SignatureKey struct {
+	Name      string `yaml:"name"`
+	Minisign  string `yaml:"minisign,omitempty"`
+	PublicKey string `yaml:"public-key,omitempty"`
+}
+
+// label names the key in log lines.
+func (k SignatureKey) label() string {
+	if k.Name != "" {
+		return k.Name
+	}
+	if k.Minisign != "" {
+		if key, err := parseMinisignKey(k.Minisign); err == nil {
+			return fmt.Sprintf("minisign %016X", key.id)
+		}
+	}
+	return "unnamed key"
+}
+
+// signatureKind describes the detached signature assets that can accompany an
+// archive and how they are checked.
+type signatureKind struct {
+	suffix string
+	verify func(key SignatureKey, data, sig []byte) (bool, error)
+}
+
+var signatureKinds = []signatureKind{
+	{suffix: ".minisig", verify: verifyMinisign},
+	{suffix: ".sig", verify: verifyPEM},
+}
+
+// verifySignature checks the archive at path against the detached signature
+// published next to it in the release. It returns the name of the key that
+// validated the archive, or an empty name when the policy allows going on
+// without one.
+func (i *Installer) verifySignature(ctx context.Context, release Release, asset Asset, path string) (string, error) {
+	if i.SignaturePolicy == "" || i.SignaturePolicy == SignatureOff {
+		return "", nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	key, err := i.checkSignature(ctx, release, asset, data)
+	if err == nil {
+		if i.Log != nil {
+			i.Log.Printf(logger.PrefixInstall, "Signature of %s validated by key %s", asset.Name, key)
+		}
+		return key, nil
+	}
+	if i.SignaturePolicy == SignatureRequire {
+		return "", err
+	}
+	if i.Log != nil {
+		i.Log.Printf(logger.PrefixInstall, "Warning: %v; installing anyway", err)
+	}
+	return "", nil
+}
+
+func (i *Installer) checkSignature(ctx context.Context, release Release, asset Asset, data []byte) (string, error) {
+	if len(i.SignatureKeys) == 0 {
+		return "", errors.New("signature verification is enabled but no signature-keys are configured")
+	}
+	found := false
+	var failures []string
+	for _, kind := range signatureKinds {
+		sigAsset, ok := release.FindAsset(asset.Name + kind.suffix)
+		if !ok {
+			continue
+		}
+		found = true
+		sig, err := i.fetch(ctx, sigAsset.URL)
+		if err != nil {
+			return "", fmt.Errorf("failed to fetch %s: %w", sigAsset.Name, err)
+		}
+		for _, key := range i.SignatureKeys {
+			ok, err := kind.verify(key, data, sig)
+			if err != nil {
+				failures = append(failures, fmt.Sprintf("%s: %v", key.label(), err))
+				continue
+			}
+			if ok {
+				return key.label(), nil
+			}
+		}
+	}
+	if !found {
+		return "", fmt.Errorf("%w for %s in %s", ErrNoSignature, asset.Name, release.Tag)
+	}
+	if len(failures) > 0 {
+		return "", fmt.Errorf("%w for %s (%s)", ErrBadSignature, asset.Name, strings.Join(failures, "; "))
+	}
+	return "", fmt.Errorf("%w for %s with any configured key", ErrBadSignature, asset.Name)
+}
+
+type minisignKey struct {
+	id  uint64
+	pub ed25519.PublicKey
+}
+
+// parseMinisignKey decodes a minisign public key, either the bare base64 line
+// or the full minisign.pub content.
+func parseMinisignKey(value string) (minisignKey, error) {
+	lines := strings.Split(strings.TrimSpace(value), "\n")
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[len(lines)-1]))
+	if err != nil || len(raw) != 42 || string(raw[:2]) != "Ed" {
+		return minisignKey{}, errors.New("malformed minisign public key")
+	}
+	return minisignKey{id: binary.LittleEndian.Uint64(raw[2:10]), pub: ed25519.PublicKey(raw[10:])}, nil
+}
+
+// verifyMinisign checks a .minisig signature, legacy or prehashed, including
+// the signature over its trusted comment. Keys that are not minisign keys or
+// carry another key id are skipped.
+func verifyMinisign(key SignatureKey, data, sig []byte) (bool, error) {
+	if key.Minisign == "" {
+		return false, nil
+	}
+	pub, err := parseMinisignKey(key.Minisign)
+	if err != nil {
+		return false, err
+	}
+	lines := strings.Split(strings.ReplaceAll(strings.TrimSpace(string(sig)), "\r\n", "\n"), "\n")
+	if len(lines) < 4 || !strings.HasPrefix(lines[2], "trusted comment: ") {
+		return false, errors.New("malformed minisign signature")
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(raw) != 74 {
+		return false, errors.New("malformed minisign signature")
+	}
+	if binary.LittleEndian.Uint64(raw[2:10]) != pub.id {
+		return false, nil
+	}
+	message := data
+	switch string(raw[:2]) {
+	case "Ed":
+	case "ED":
+		sum := blake2b.Sum512(data)
+		message = sum[:]
+	default:
+		return false, fmt.Errorf("unsupported minisign algorithm %q", raw[:2])
+	}
+	signature := raw[10:]
+	if !ed25519.Verify(pub.pub, message, signature) {
+		return false, nil
+	}
+	global, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || len(global) != ed25519.SignatureSize {
+		return false, errors.New("malformed minisign trusted comment signature")
+	}
+	comment := strings.TrimPrefix(lines[2], "trusted comment: ")
+	if !ed25519.Verify(pub.pub, append(bytes.Clone(signature), comment...), global) {
+		return false, errors.New("trusted comment signature does not verify")
+	}
+	return true, nil
+}
+
+// verifyPEM checks a cosign sign-blob style signature: base64 (or raw) bytes
+// made with the PEM encoded key over the SHA-256 of the archive, or over the
+// archive itself for Ed25519.
+func verifyPEM(key SignatureKey, data, sig []byte) (bool, error) {
+	if key.PublicKey == "" {
+		return false, nil
+	}
+	block, _ := pem.Decode([]byte(key.PublicKey))
+	if block == nil {
+		return false, errors.New("public-key is not PEM encoded")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return false, err
+	}
+	if decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig))); err == nil {
+		sig = decoded
+	}
+	digest := sha256.Sum256(data)
+	switch pub := pub.(type) {
+	case *ecdsa.PublicKey:
+		return ecdsa.VerifyASN1(pub, digest[:], sig), nil
+	case ed25519.PublicKey:
+		return ed25519.Verify(pub, data, sig), nil
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig) == nil, nil
+	}
+	return false, fmt.Errorf("unsupported public key type %T", pub)
+}
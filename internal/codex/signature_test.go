@@ -0,0 +1,133 @@
+package codex
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// The vectors below were made with a fixed Ed25519 seed (bytes 1..32) and
+// key id 0x1122334455667788; the prehashed signature signs the BLAKE2b-512
+// digest computed by Python's hashlib.
+const (
+	testSignedData    = "codex release archive\n"
+	testMinisignKey   = "RWSId2ZVRDMiEXm1Vi6P5lT5QHixEuipi6eQH4U65pW+1+DjkQutBJZk"
+	testMinisigLegacy = `untrusted comment: signature from minisign secret key
+RWSId2ZVRDMiETsIRj1KT31Wc9QEQhYMV6Z74k5TDEseVmhIJPxDKkLXZGFIk4iCdGeNbL5na+CWwdHetg2OoeUzGc8VdlReYAs=
+trusted comment: timestamp:1760000000	file:codex.tar.gz
+lBmSYahiPn/4xzo/sONNtYW5Vp6PITrAk8w646JVFNbMX0i3i5jhSAZGhD5L5OJ8oD5yvHRVjhZ95u77HTkCCA==
+`
+	testMinisigPrehashed = `untrusted comment: signature from minisign secret key
+RUSId2ZVRDMiEYnb9TLIuqP0z6eVDITDH1xSfLeLYBK+f7FvA57t5q4vAZ5GH/HOxmc2+GiRtnXqT0ZLvE0xg9JW7+xh/NcU9Ag=
+trusted comment: timestamp:1760000000	file:codex.tar.gz
+GwZtk+IojSyx8jA9NOCh+pGGPTqkU8Ydpmgjut0u6so+cS88bLmvh2O1rO1RxAupnOXkbc6VddJzNGe2cG7rBQ==
+`
+	testECDSAKey = `-----BEGIN PUBLIC KEY-----
+MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE3H6jG4MYOyAKHftk9lsJJjn+YmgU
+ErrMbzLtdzuIPvTmHq40wL51SDfS64mBIdtbOAvfvnM6idC5OYPM6GjY6w==
+-----END PUBLIC KEY-----
+`
+	testECDSASig   = "MEUCIQCoEKAgnTuLFYyQeJ3pGtZ/sYVPRsTM3AM7SCUDNAv45AIgPnoZmWnx9C09wTK4RMbnsWxNWKFrRsc4sVhyUHKGbOE="
+	testEd25519Key = `-----BEGIN PUBLIC KEY-----
+MCowBQYDK2VwAyEAebVWLo/mVPlAeLES6KmLp5AfhTrmlb7X4OORC60ElmQ=
+-----END PUBLIC KEY-----
+`
+	testEd25519Sig = "OwhGPUpPfVZz1ARCFgxXpnviTlMMSx5WaEgk/EMqQtdkYUiTiIJ0Z41svmdr4JbB0d62DY6h5TMZzxV2VF5gCw=="
+	// testOtherMinisignKey has a different key id.
+	testOtherMinisignKey = "RWQBAAAAAAAAAHm1Vi6P5lT5QHixEuipi6eQH4U65pW+1+DjkQutBJZk"
+)
+
+func TestVerifyMinisign(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		data    string
+		sig     string
+		want    bool
+		wantErr bool
+	}{
+		{name: "legacy", key: testMinisignKey, data: testSignedData, sig: testMinisigLegacy, want: true},
+		{name: "prehashed", key: testMinisignKey, data: testSignedData, sig: testMinisigPrehashed, want: true},
+		{name: "full minisign.pub", key: "untrusted comment: minisign public key\n" + testMinisignKey, data: testSignedData, sig: testMinisigPrehashed, want: true},
+		{name: "tampered data", key: testMinisignKey, data: testSignedData + "x", sig: testMinisigPrehashed},
+		{name: "other key id", key: testOtherMinisignKey, data: testSignedData, sig: testMinisigLegacy},
+		{name: "tampered trusted comment", key: testMinisignKey, data: testSignedData, sig: strings.Replace(testMinisigLegacy, "1760000000", "1760000001", 1), wantErr: true},
+		{name: "truncated", key: testMinisignKey, data: testSignedData, sig: "untrusted comment: x\n", wantErr: true},
+		{name: "malformed key", key: "not-a-key", data: testSignedData, sig: testMinisigLegacy, wantErr: true},
+		{name: "not a minisign key", key: "", data: testSignedData, sig: testMinisigLegacy},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := verifyMinisign(SignatureKey{Minisign: tt.key}, []byte(tt.data), []byte(tt.sig))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("verifyMinisign error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("verifyMinisign = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyPEM(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		data    string
+		sig     string
+		want    bool
+		wantErr bool
+	}{
+		{name: "ecdsa", key: testECDSAKey, data: testSignedData, sig: testECDSASig, want: true},
+		{name: "ed25519", key: testEd25519Key, data: testSignedData, sig: testEd25519Sig, want: true},
+		{name: "ecdsa tampered", key: testECDSAKey, data: "other", sig: testECDSASig},
+		{name: "ed25519 tampered", key: testEd25519Key, data: "other", sig: testEd25519Sig},
+		{name: "wrong key", key: testEd25519Key, data: testSignedData, sig: testECDSASig},
+		{name: "not pem", key: "garbage", data: testSignedData, sig: testECDSASig, wantErr: true},
+		{name: "no key", key: "", data: testSignedData, sig: testECDSASig},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := verifyPEM(SignatureKey{PublicKey: tt.key}, []byte(tt.data), []byte(tt.sig))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("verifyPEM error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("verifyPEM = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSignaturePolicy(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    SignaturePolicy
+		wantErr bool
+	}{
+		{"", SignatureOff, false},
+		{"off", SignatureOff, false},
+		{" Warn ", SignatureWarn, false},
+		{"REQUIRE", SignatureRequire, false},
+		{"strict", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSignaturePolicy(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseSignaturePolicy(%q) = %q, %v; want %q, error %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestCheckSignatureFailsClosed(t *testing.T) {
+	release := Release{Tag: "rust-v1.0.0", Assets: []Asset{{Name: "codex.tar.gz"}}}
+	installer := Installer{SignatureKeys: []SignatureKey{{Name: "release", Minisign: testMinisignKey}}}
+	_, err := installer.checkSignature(t.Context(), release, release.Assets[0], []byte(testSignedData))
+	if !errors.Is(err, ErrNoSignature) {
+		t.Fatalf("checkSignature without a signature asset = %v, want ErrNoSignature", err)
+	}
+	installer.SignatureKeys = nil
+	if _, err := installer.checkSignature(t.Context(), release, release.Assets[0], []byte(testSignedData)); err == nil {
+		t.Fatal("checkSignature without keys succeeded")
+	}
+}
@@ -0,0 +1,222 @@
+package codex
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"codex-control/internal/fsx"
+)
+
+const (
+	storeBinary    = "codex"
+	storeManifest  = "manifest.json"
+	storeStateName = "state.json"
+
+	// DefaultKeepVersions is how many installed versions are retained.
+	DefaultKeepVersions = 3
+)
+
+// InstalledVersion describes a Codex release kept in the version store.
+type InstalledVersion struct {
+	Version     string    `json:"version"`
+	Path        string    `json:"path"`
+	Archive     string    `json:"archive,omitempty"`
+	Digest      string    `json:"digest,omitempty"`
+	SignedBy    string    `json:"signed_by,omitempty"`
+	InstalledAt time.Time `json:"installed_at"`
+	Active      bool      `json:"active,omitempty"`
+}
+
+// storeState remembers the active version and the one it replaced, which
+// rollback returns to.
+type storeState struct {
+	Current  string `json:"current"`
+	Previous string `json:"previous,omitempty"`
+}
+
+// Store keeps every installed Codex release in <Dir>/<tag>/codex so the
+// target can be switched between them without downloading again.
+type Store struct {
+	Dir string
+}
+
+// DefaultStoreDir returns $XDG_DATA_HOME/codex-control/versions, falling back
+// to ~/.local/share/codex-control/versions.
+func DefaultStoreDir() (string, error) {
+	if data := os.Getenv("XDG_DATA_HOME"); data != "" {
+		return filepath.Join(data, "codex-control", "versions"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share", "codex-control", "versions"), nil
+}
+
+// Add copies binary into the store under version.Version and records its
+// manifest.
+func (s Store) Add(binary string, version InstalledVersion) (InstalledVersion, error) {
+	dir, err := s.versionDir(version.Version)
+	if err != nil {
+		return InstalledVersion{}, err
+	}
+	version.Path = filepath.Join(dir, storeBinary)
+	if err := fsx.CopyFile(binary, version.Path, 0o755); err != nil {
+		return InstalledVersion{}, err
+	}
+	version.Active = false
+	raw, err := json.MarshalIndent(version, "", "  ")
+	if err != nil {
+		return InstalledVersion{}, err
+	}
+	if err := fsx.WriteFile(filepath.Join(dir, storeManifest), raw, 0o644); err != nil {
+		return InstalledVersion{}, err
+	}
+	return version, nil
+}
+
+// Get returns the stored version tag. The boolean is false when it is not
+// installed.
+func (s Store) Get(tag string) (InstalledVersion, bool, error) {
+	dir, err := s.versionDir(tag)
+	if err != nil {
+		return InstalledVersion{}, false, err
+	}
+	raw, err := os.ReadFile(filepath.Join(dir, storeManifest))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return InstalledVersion{}, false, nil
+		}
+		return InstalledVersion{}, false, err
+	}
+	var version InstalledVersion
+	if err := json.Unmarshal(raw, &version); err != nil {
+		return InstalledVersion{}, false, fmt.Errorf("%s: %w", tag, err)
+	}
+	version.Path = filepath.Join(dir, storeBinary)
+	if _, err := os.Stat(version.Path); err != nil {
+		return InstalledVersion{}, false, nil
+	}
+	state, err := s.loadState()
+	if err != nil {
+		return InstalledVersion{}, false, err
+	}
+	version.Active = state.Current == version.Version
+	return version, true, nil
+}
+
+// List returns the stored versions, most recently installed first.
+func (s Store) List() ([]InstalledVersion, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []InstalledVersion{}, nil
+		}
+		return nil, err
+	}
+	versions := []InstalledVersion{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		version, ok, err := s.Get(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			versions = append(versions, version)
+		}
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].InstalledAt.After(versions[j].InstalledAt)
+	})
+	return versions, nil
+}
+
+// Current returns the active and the previously active version tags.
+func (s Store) Current() (string, string, error) {
+	state, err := s.loadState()
+	return state.Current, state.Previous, err
+}
+
+// SetCurrent records tag as the active version, keeping the one it replaces
+// for rollback.
+func (s Store) SetCurrent(tag string) error {
+	state, err := s.loadState()
+	if err != nil {
+		return err
+	}
+	if state.Current != tag {
+		state.Previous = state.Current
+		state.Current = tag
+	}
+	raw, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fsx.WriteFile(filepath.Join(s.Dir, storeStateName), raw, 0o644)
+}
+
+// Prune removes the oldest versions beyond keep, never touching the active
+// and previous ones. A keep below one disables pruning. It returns the
+// removed tags.
+func (s Store) Prune(keep int) ([]string, error) {
+	if keep < 1 {
+		return nil, nil
+	}
+	versions, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	state, err := s.loadState()
+	if err != nil {
+		return nil, err
+	}
+	var pruned []string
+	kept := 0
+	for _, version := range versions {
+		protected := version.Version == state.Current || version.Version == state.Previous
+		if protected || kept < keep {
+			kept++
+			continue
+		}
+		if err := os.RemoveAll(filepath.Dir(version.Path)); err != nil {
+			return pruned, err
+		}
+		pruned = append(pruned, version.Version)
+	}
+	return pruned, nil
+}
+
+func (s Store) loadState() (storeState, error) {
+	raw, err := os.ReadFile(filepath.Join(s.Dir, storeStateName))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return storeState{}, nil
+		}
+		return storeState{}, err
+	}
+	var state storeState
+	if err := json.Unmarshal(raw, &state); err != nil {
+		return storeState{}, err
+	}
+	return state, nil
+}
+
+// versionDir returns the folder of tag, rejecting tags that would escape the
+// store.
+func (s Store) versionDir(tag string) (string, error) {
+	if s.Dir == "" {
+		return "", errors.New("version store path is empty")
+	}
+	if tag == "" || tag == "." || tag == ".." || strings.ContainsAny(tag, `/\`) {
+		return "", fmt.Errorf("invalid version tag %q", tag)
+	}
+	return filepath.Join(s.Dir, tag), nil
+}
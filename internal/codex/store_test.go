@@ -0,0 +1,91 @@
+package codex
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// newTestStore fills a store with tags installed one hour apart, oldest
+// first, and marks current and previous.
+func newTestStore(t *testing.T, tags []string, current, previous string) Store {
+	t.Helper()
+	store := Store{Dir: filepath.Join(t.TempDir(), "versions")}
+	binary := filepath.Join(t.TempDir(), "codex")
+	if err := os.WriteFile(binary, []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, tag := range tags {
+		if _, err := store.Add(binary, InstalledVersion{Version: tag, InstalledAt: start.Add(time.Duration(i) * time.Hour)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, tag := range []string{previous, current} {
+		if tag == "" {
+			continue
+		}
+		if err := store.SetCurrent(tag); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return store
+}
+
+func TestStorePrune(t *testing.T) {
+	tags := []string{"v1", "v2", "v3", "v4", "v5"}
+	tests := []struct {
+		name       string
+		keep       int
+		current    string
+		previous   string
+		wantPruned []string
+		wantKept   []string
+	}{
+		{name: "keeps newest", keep: 3, current: "v5", previous: "v4", wantPruned: []string{"v2", "v1"}, wantKept: []string{"v5", "v4", "v3"}},
+		{name: "protects rolled back versions", keep: 2, current: "v1", previous: "v2", wantPruned: []string{"v3"}, wantKept: []string{"v5", "v4", "v2", "v1"}},
+		{name: "protects old previous", keep: 1, current: "v5", previous: "v1", wantPruned: []string{"v4", "v3", "v2"}, wantKept: []string{"v5", "v1"}},
+		{name: "nothing to prune", keep: 5, current: "v5", wantKept: []string{"v5", "v4", "v3", "v2", "v1"}},
+		{name: "disabled", keep: 0, current: "v5", wantKept: []string{"v5", "v4", "v3", "v2", "v1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTestStore(t, tags, tt.current, tt.previous)
+			pruned, err := store.Prune(tt.keep)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(pruned) != 0 || len(tt.wantPruned) != 0 {
+				if !reflect.DeepEqual(pruned, tt.wantPruned) {
+					t.Errorf("pruned = %v, want %v", pruned, tt.wantPruned)
+				}
+			}
+			versions, err := store.List()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var kept []string
+			for _, version := range versions {
+				kept = append(kept, version.Version)
+			}
+			if !reflect.DeepEqual(kept, tt.wantKept) {
+				t.Errorf("kept = %v, want %v", kept, tt.wantKept)
+			}
+			current, previous, err := store.Current()
+			if err != nil || current != tt.current || previous != tt.previous {
+				t.Errorf("Current = %q, %q, %v; want %q, %q", current, previous, err, tt.current, tt.previous)
+			}
+		})
+	}
+}
+
+func TestStoreRejectsEscapingTags(t *testing.T) {
+	store := Store{Dir: t.TempDir()}
+	for _, tag := range []string{"", ".", "..", "../x", `a\b`} {
+		if _, _, err := store.Get(tag); err == nil {
+			t.Errorf("Get(%q) succeeded, want an error", tag)
+		}
+	}
+}
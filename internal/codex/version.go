@@ -0,0 +1,92 @@
+package codex
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var versionPattern = regexp.MustCompile(`(\d+)\.(\d+)\.(\d+)(?:-([0-9A-Za-z.-]+))?`)
+
+// Version is a semantic version found in a release tag or in the output of
+// codex --version.
+type Version struct {
+	Major, Minor, Patch int
+	Pre                 []string
+}
+
+// ParseVersion extracts the first semantic version from value, so tags such
+// as "rust-v0.50.0" and output such as "codex-cli 0.50.0" both parse.
+func ParseVersion(value string) (Version, bool) {
+	match := versionPattern.FindStringSubmatch(value)
+	if match == nil {
+		return Version{}, false
+	}
+	var v Version
+	v.Major, _ = strconv.Atoi(match[1])
+	v.Minor, _ = strconv.Atoi(match[2])
+	v.Patch, _ = strconv.Atoi(match[3])
+	if match[4] != "" {
+		v.Pre = strings.Split(match[4], ".")
+	}
+	return v, true
+}
+
+// Compare returns -1, 0 or 1 when v is older than, equal to or newer than
+// other, following semver precedence.
+func (v Version) Compare(other Version) int {
+	for _, pair := range [][2]int{{v.Major, other.Major}, {v.Minor, other.Minor}, {v.Patch, other.Patch}} {
+		if c := compareInts(pair[0], pair[1]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(v.Pre) == 0 && len(other.Pre) == 0:
+		return 0
+	case len(v.Pre) == 0:
+		return 1
+	case len(other.Pre) == 0:
+		return -1
+	}
+	for i := 0; i < len(v.Pre) && i < len(other.Pre); i++ {
+		if c := comparePrerelease(v.Pre[i], other.Pre[i]); c != 0 {
+			return c
+		}
+	}
+	return compareInts(len(v.Pre), len(other.Pre))
+}
+
+// String renders the version without any tag prefix.
+func (v Version) String() string {
+	s := strconv.Itoa(v.Major) + "." + strconv.Itoa(v.Minor) + "." + strconv.Itoa(v.Patch)
+	if len(v.Pre) > 0 {
+		s += "-" + strings.Join(v.Pre, ".")
+	}
+	return s
+}
+
+// comparePrerelease orders numeric identifiers numerically and below
+// alphanumeric ones, which compare lexically.
+func comparePrerelease(a, b string) int {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	switch {
+	case errA == nil && errB == nil:
+		return compareInts(na, nb)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,65 @@
+package codex
+
+import "testing"
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		value  string
+		want   string
+		wantOK bool
+	}{
+		{value: "rust-v0.50.0", want: "0.50.0", wantOK: true},
+		{value: "codex-cli 0.50.0\n", want: "0.50.0", wantOK: true},
+		{value: "v1.2.3-alpha.1", want: "1.2.3-alpha.1", wantOK: true},
+		{value: "rust-v0.47.0-beta.2", want: "0.47.0-beta.2", wantOK: true},
+		{value: "codex 10.0.12 (build 1.2.3)", want: "10.0.12", wantOK: true},
+		{value: "1.2", wantOK: false},
+		{value: "nightly", wantOK: false},
+		{value: "", wantOK: false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseVersion(tt.value)
+		if ok != tt.wantOK {
+			t.Errorf("ParseVersion(%q) ok = %v, want %v", tt.value, ok, tt.wantOK)
+			continue
+		}
+		if ok && got.String() != tt.want {
+			t.Errorf("ParseVersion(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"0.50.0", "0.50.0", 0},
+		{"rust-v0.50.0", "codex-cli 0.50.0", 0},
+		{"0.49.0", "0.50.0", -1},
+		{"0.9.0", "0.10.0", -1},
+		{"1.0.0", "0.99.99", 1},
+		{"0.50.1", "0.50.0", 1},
+		// Semver precedence: 1.0.0-alpha < 1.0.0-alpha.1 < 1.0.0-alpha.beta <
+		// 1.0.0-beta < 1.0.0-beta.2 < 1.0.0-beta.11 < 1.0.0-rc.1 < 1.0.0.
+		{"1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{"1.0.0-alpha.1", "1.0.0-alpha.beta", -1},
+		{"1.0.0-alpha.beta", "1.0.0-beta", -1},
+		{"1.0.0-beta", "1.0.0-beta.2", -1},
+		{"1.0.0-beta.2", "1.0.0-beta.11", -1},
+		{"1.0.0-beta.11", "1.0.0-rc.1", -1},
+		{"1.0.0-rc.1", "1.0.0", -1},
+		{"1.0.0", "1.0.0-rc.1", 1},
+		{"1.0.0-rc.1", "1.0.0-rc.1", 0},
+	}
+	for _, tt := range tests {
+		a, okA := ParseVersion(tt.a)
+		b, okB := ParseVersion(tt.b)
+		if !okA || !okB {
+			t.Fatalf("ParseVersion(%q, %q) failed", tt.a, tt.b)
+		}
+		if got := a.Compare(b); got != tt.want {
+			t.Errorf("Compare(%s, %s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
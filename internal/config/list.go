@@ -0,0 +1,48 @@
+package config
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// StringList is a config value written either as a single string or as a
+// list of strings. Empty strings are dropped.
+type StringList []string
+
+// UnmarshalYAML accepts a scalar or a sequence of scalars.
+func (l *StringList) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		*l = nil
+		if node.Value != "" && node.Tag != "!!null" {
+			*l = StringList{node.Value}
+		}
+		return nil
+	case yaml.SequenceNode:
+		var items []string
+		if err := node.Decode(&items); err != nil {
+			return err
+		}
+		*l = nil
+		for _, item := range items {
+			if item != "" {
+				*l = append(*l, item)
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("line %d: expected a string or a list of strings", node.Line)
+}
+
+// MarshalYAML keeps single values as plain strings so existing config files
+// stay readable.
+func (l StringList) MarshalYAML() (any, error) {
+	switch len(l) {
+	case 0:
+		return "", nil
+	case 1:
+		return l[0], nil
+	}
+	return []string(l), nil
+}
@@ -3,11 +3,13 @@ package env
 import (
 	"os"
 	"path/filepath"
+
+	"codex-control/internal/fsx"
 )
 
 const (
 	defaultWorkspace = "/tmp/codex-control"
-	targetBinary     = "/usr/bin/codex"
+	systemTarget     = "/usr/bin/codex"
 )
 
 // PrepareWorkspace ensures the shared workspace directory exists and is empty.
@@ -32,7 +34,17 @@ func CleanupWorkspace(path string) error {
 	return os.RemoveAll(path)
 }
 
-// TargetBinaryPath returns the final installation path for Codex.
-func TargetBinaryPath() string {
-	return targetBinary
+// DefaultTargetPath returns where Codex is installed when no target is
+// configured: ~/.local/bin/codex when that folder is writable or can be
+// created, /usr/bin/codex otherwise.
+func DefaultTargetPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return systemTarget
+	}
+	dir := filepath.Join(home, ".local", "bin")
+	if !fsx.Writable(dir) {
+		return systemTarget
+	}
+	return filepath.Join(dir, "codex")
 }
@@ -0,0 +1,51 @@
+package fsx
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// lockPollInterval is how often a busy lock is retried.
+const lockPollInterval = 50 * time.Millisecond
+
+// ErrLockTimeout reports that another process held a lock for too long.
+var ErrLockTimeout = errors.New("timed out waiting for lock")
+
+// errLockBusy is returned by tryLock while another process holds the lock.
+var errLockBusy = errors.New("lock is held elsewhere")
+
+// Lock takes an exclusive advisory lock on path, creating the file when
+// needed, and waits at most timeout for other holders to release it. The
+// returned function releases the lock. Locks are not reentrant.
+func Lock(path string, timeout time.Duration) (func() error, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		err := tryLock(file)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, errLockBusy) {
+			file.Close()
+			return nil, err
+		}
+		if time.Now().After(deadline) {
+			file.Close()
+			return nil, fmt.Errorf("%w on %s after %s (is another codex-auth running?)", ErrLockTimeout, path, timeout)
+		}
+		time.Sleep(lockPollInterval)
+	}
+	return func() error {
+		defer file.Close()
+		return unlock(file)
+	}, nil
+}
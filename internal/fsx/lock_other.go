@@ -0,0 +1,15 @@
+//go:build !unix && !windows
+
+package fsx
+
+import "os"
+
+// tryLock always succeeds on platforms without advisory file locks, so
+// concurrent processes are not serialized there.
+func tryLock(*os.File) error {
+	return nil
+}
+
+func unlock(*os.File) error {
+	return nil
+}
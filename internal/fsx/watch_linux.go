@@ -0,0 +1,128 @@
+package fsx
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+const (
+	watchMask = syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_CLOSE_WRITE | syscall.IN_MOVED_FROM |
+		syscall.IN_MOVED_TO | syscall.IN_DELETE_SELF | syscall.IN_ATTRIB
+	// watchDebounce folds bursts of events, such as a temp file write
+	// followed by a rename, into a single notification.
+	watchDebounce = 150 * time.Millisecond
+)
+
+// Watch calls notify after files change inside dirs or their non-hidden
+// subfolders, until ctx is done. keep receives paths relative to their dir
+// with / separators; events on paths it rejects are ignored and folders it
+// rejects are not watched. A nil keep accepts everything. Subfolders created
+// later are watched under the same rules.
+func Watch(ctx context.Context, dirs []string, keep func(rel string) bool, notify func()) error {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return err
+	}
+	file := os.NewFile(uintptr(fd), "inotify")
+	defer file.Close()
+	type watchedDir struct {
+		root string
+		path string
+	}
+	watched := map[int]watchedDir{}
+	relPath := func(root, path string) string {
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return filepath.Base(path)
+		}
+		return filepath.ToSlash(rel)
+	}
+	// add watches start and its subfolders, skipping hidden and rejected
+	// folders below root the same way the initial walk does.
+	add := func(root, start string) error {
+		return filepath.WalkDir(start, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !entry.IsDir() {
+				return nil
+			}
+			if path != root && (strings.HasPrefix(entry.Name(), ".") || keep != nil && !keep(relPath(root, path))) {
+				return filepath.SkipDir
+			}
+			wd, err := syscall.InotifyAddWatch(fd, path, watchMask)
+			if err != nil {
+				return err
+			}
+			watched[wd] = watchedDir{root: root, path: path}
+			return nil
+		})
+	}
+	for _, dir := range dirs {
+		if err := add(dir, dir); err != nil {
+			return err
+		}
+	}
+
+	events := make(chan []string)
+	readErr := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+		for {
+			n, err := file.Read(buf)
+			if err != nil {
+				readErr <- err
+				return
+			}
+			var batch []string
+			for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+				event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+				nameBytes := buf[offset+syscall.SizeofInotifyEvent : offset+syscall.SizeofInotifyEvent+int(event.Len)]
+				name := strings.TrimRight(string(nameBytes), "\x00")
+				if dir, ok := watched[int(event.Wd)]; ok && name != "" {
+					path := filepath.Join(dir.path, name)
+					if event.Mask&syscall.IN_ISDIR != 0 && event.Mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0 {
+						_ = add(dir.root, path)
+					}
+					batch = append(batch, relPath(dir.root, path))
+				}
+				offset += syscall.SizeofInotifyEvent + int(event.Len)
+			}
+			select {
+			case events <- batch:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var timer <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-readErr:
+			if errors.Is(err, os.ErrClosed) {
+				return nil
+			}
+			return err
+		case batch := <-events:
+			for _, rel := range batch {
+				if keep == nil || keep(rel) {
+					timer = time.After(watchDebounce)
+					break
+				}
+			}
+		case <-timer:
+			timer = nil
+			notify()
+		}
+	}
+}
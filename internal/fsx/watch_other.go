@@ -0,0 +1,13 @@
+//go:build !linux
+
+package fsx
+
+import (
+	"context"
+	"errors"
+)
+
+// Watch is only implemented on Linux, where it uses inotify.
+func Watch(ctx context.Context, dirs []string, keep func(rel string) bool, notify func()) error {
+	return errors.New("watching folders is not supported on this platform")
+}
@@ -0,0 +1,28 @@
+package fsx
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// Writable reports whether the current user can create files in dir. A
+// missing dir counts as writable when its closest existing parent is, since
+// it can then be created.
+func Writable(dir string) bool {
+	dir = filepath.Clean(dir)
+	for {
+		info, err := os.Stat(dir)
+		if err == nil {
+			return info.IsDir() && canWrite(dir)
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return false
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return false
+		}
+		dir = parent
+	}
+}
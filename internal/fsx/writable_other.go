@@ -0,0 +1,17 @@
+//go:build !unix
+
+package fsx
+
+import "os"
+
+// canWrite probes dir by creating and removing a temporary file, since
+// access(2) is not available here.
+func canWrite(dir string) bool {
+	file, err := os.CreateTemp(dir, ".codex-write-probe-*")
+	if err != nil {
+		return false
+	}
+	name := file.Name()
+	file.Close()
+	return os.Remove(name) == nil
+}
@@ -0,0 +1,10 @@
+//go:build unix
+
+package fsx
+
+import "golang.org/x/sys/unix"
+
+// canWrite asks the kernel whether the current user may write to dir.
+func canWrite(dir string) bool {
+	return unix.Access(dir, unix.W_OK) == nil
+}
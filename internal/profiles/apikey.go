@@ -0,0 +1,36 @@
+package profiles
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"codex-control/internal/auth"
+)
+
+// Environment returns the environment variables and Codex config overrides
+// of the installed profile when it is an API key profile. Other profiles need
+// neither, so it returns nothing for them.
+func Environment() ([]string, []string, error) {
+	state, ok, err := auth.LoadInstallState()
+	if err != nil || !ok || !state.APIProfile {
+		return nil, nil, err
+	}
+	settings, err := LoadConfig()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load codex-auth config: %w", err)
+	}
+	passphrase := Passphrase{File: settings.PassphraseFile}
+	profile, ok, err := auth.LoadAPIProfile(state.Source, passphrase.Func(false))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, nil
+	}
+	if err != nil || !ok {
+		return nil, nil, err
+	}
+	key, err := profile.ResolveKey()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", state.Profile, err)
+	}
+	return profile.Environ(key), profile.ConfigOverrides(), nil
+}
@@ -0,0 +1,43 @@
+// Package profiles switches Codex auth profiles on behalf of the command line
+// tools. It holds the codex-auth configuration and the install, lease, sync
+// and rotation steps that codex-auth and the codex-yolo wrappers share.
+package profiles
+
+import (
+	"codex-control/internal/auth"
+	"codex-control/internal/config"
+)
+
+// configCommand names the config file every tool reads profile settings from.
+const configCommand = "codex-auth"
+
+// Config is the codex-auth configuration.
+type Config struct {
+	Verbosity int `yaml:"verbosity"`
+	// AuthsPath lists one or more folders holding profiles. The first one
+	// stores new profiles and the usage data.
+	AuthsPath      config.StringList `yaml:"auths-path"`
+	BackupLimit    int               `yaml:"backup-limit"`
+	PassphraseFile string            `yaml:"passphrase-file"`
+	// CodexHome overrides CODEX_HOME for the installed auth.json.
+	CodexHome string `yaml:"codex-home"`
+	// Isolate installs each profile into its own Codex home under HomesPath.
+	Isolate   bool   `yaml:"isolate"`
+	HomesPath string `yaml:"homes-path"`
+	// LeaseTTL is how long an install reserves a profile for the current
+	// user. "0" disables leases.
+	LeaseTTL string `yaml:"lease-ttl"`
+	// Ignore lists glob patterns of files in the auth folders that are not
+	// profiles.
+	Ignore config.StringList `yaml:"ignore"`
+}
+
+// LoadConfig reads the codex-auth YAML config, creating it when missing.
+func LoadConfig() (Config, error) {
+	defaults := Config{Verbosity: 1, AuthsPath: config.StringList{}, BackupLimit: auth.DefaultBackupLimit, CodexHome: "", Isolate: false, HomesPath: "", LeaseTTL: auth.DefaultLeaseTTL.String(), Ignore: auth.DefaultIgnorePatterns}
+	var settings Config
+	if _, err := config.Load(configCommand, defaults, &settings); err != nil {
+		return Config{}, err
+	}
+	return settings, nil
+}
@@ -0,0 +1,88 @@
+package profiles
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"codex-control/internal/auth"
+	"codex-control/internal/logger"
+)
+
+// ParseLeaseTTL reads the lease-ttl setting; zero disables leases.
+func ParseLeaseTTL(value string) (time.Duration, error) {
+	if value == "" || value == "0" {
+		return 0, nil
+	}
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl < 0 {
+		return 0, fmt.Errorf("invalid lease-ttl %q", value)
+	}
+	return ttl, nil
+}
+
+// Lease reserves the profile at path for the current user. Force takes over
+// a lease held by someone else.
+func (s *Switcher) Lease(path string) error {
+	if s.LeaseTTL == 0 {
+		return nil
+	}
+	_, err := auth.AcquireLease(path, s.LeaseTTL, s.Force)
+	return err
+}
+
+// ReleaseLease drops the current user's lease on path, reporting failures
+// without stopping the caller.
+func (s *Switcher) ReleaseLease(path string) {
+	if s.LeaseTTL == 0 {
+		return
+	}
+	if err := auth.ReleaseLease(path); err != nil {
+		s.Log.Errorf(logger.PrefixAuth, "Failed to release lease on %s: %v", path, err)
+	}
+}
+
+// HoldLease keeps the lease on the installed profile alive until ctx ends or
+// the returned function is called, which also releases it. The installed
+// profile is looked up on every renewal so rotations are followed.
+func HoldLease(ctx context.Context, log *logger.Logger) (func(), error) {
+	s, err := load(log)
+	if err != nil {
+		return nil, err
+	}
+	if s.LeaseTTL == 0 {
+		return func() {}, nil
+	}
+	renew := func() {
+		state, ok, err := auth.LoadInstallState()
+		if err != nil || !ok {
+			return
+		}
+		if err := s.Lease(state.Source); err != nil {
+			log.Errorf(logger.PrefixAuth, "Failed to renew lease on %s: %v", state.Profile, err)
+		}
+	}
+	renew()
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(s.LeaseTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				renew()
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+		if state, ok, err := auth.LoadInstallState(); err == nil && ok {
+			s.ReleaseLease(state.Source)
+		}
+	}, nil
+}
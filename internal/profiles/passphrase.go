@@ -0,0 +1,79 @@
+package profiles
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+
+	"codex-control/internal/auth"
+)
+
+const passphraseEnv = "CODEX_AUTH_PASSPHRASE"
+
+// Passphrase resolves the profile passphrase from the environment, File or
+// an interactive prompt, in that order.
+type Passphrase struct {
+	File string
+}
+
+// NonInteractive reports whether the passphrase can be read without a prompt.
+func (p Passphrase) NonInteractive() bool {
+	return os.Getenv(passphraseEnv) != "" || p.File != ""
+}
+
+// Func returns a cached PassphraseFunc. When confirm is set an interactive
+// prompt asks twice, which protects against typos when encrypting.
+func (p Passphrase) Func(confirm bool) auth.PassphraseFunc {
+	return auth.CachedPassphrase(func() ([]byte, error) {
+		if value := os.Getenv(passphraseEnv); value != "" {
+			return []byte(value), nil
+		}
+		if p.File != "" {
+			raw, err := os.ReadFile(p.File)
+			if err != nil {
+				return nil, fmt.Errorf("read passphrase file: %w", err)
+			}
+			secret := bytes.TrimRight(raw, "\r\n")
+			if len(secret) == 0 {
+				return nil, fmt.Errorf("passphrase file %s is empty", p.File)
+			}
+			return secret, nil
+		}
+		return promptPassphrase(confirm)
+	})
+}
+
+func promptPassphrase(confirm bool) ([]byte, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("%w: set %s, configure passphrase-file or run in a terminal", auth.ErrPassphraseRequired, passphraseEnv)
+	}
+	defer tty.Close()
+	read := func(label string) ([]byte, error) {
+		fmt.Fprint(tty, label)
+		secret, err := term.ReadPassword(int(tty.Fd()))
+		fmt.Fprintln(tty)
+		return secret, err
+	}
+	secret, err := read("Profile passphrase: ")
+	if err != nil {
+		return nil, err
+	}
+	if len(strings.TrimSpace(string(secret))) == 0 {
+		return nil, auth.ErrPassphraseRequired
+	}
+	if confirm {
+		again, err := read("Repeat passphrase: ")
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(secret, again) {
+			return nil, errors.New("passphrases do not match")
+		}
+	}
+	return secret, nil
+}
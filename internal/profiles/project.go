@@ -0,0 +1,121 @@
+package profiles
+
+import (
+	"fmt"
+	"os"
+
+	"codex-control/internal/auth"
+	"codex-control/internal/logger"
+)
+
+// ProjectResult reports the outcome of installing a .codex-profile binding.
+type ProjectResult struct {
+	Binding   auth.ProjectBinding `json:"binding"`
+	Profile   string              `json:"profile"`
+	Installed bool                `json:"installed"`
+	Copy      *auth.CopyResult    `json:"copy,omitempty"`
+}
+
+// InstallBound installs the bound profile unless auth.json already holds it.
+func (s *Switcher) InstallBound(binding auth.ProjectBinding, file auth.File, trigger string) (ProjectResult, error) {
+	result := ProjectResult{Binding: binding, Profile: file.Name}
+	if s.Isolate {
+		if _, err := s.EnterHome(file); err != nil {
+			return result, err
+		}
+	}
+	installed, err := auth.IsInstalled(file.Path)
+	if err != nil {
+		return result, err
+	}
+	if installed {
+		return result, s.Lease(file.Path)
+	}
+	copyResult, err := s.Activate(file, trigger)
+	if err != nil {
+		return result, err
+	}
+	result.Installed = true
+	result.Copy = &copyResult
+	return result, nil
+}
+
+// CurrentBinding finds the .codex-profile that applies to the working
+// directory.
+func CurrentBinding() (auth.ProjectBinding, bool, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return auth.ProjectBinding{}, false, err
+	}
+	return auth.FindProjectBinding(cwd)
+}
+
+// InstallProjectProfile installs the profile bound to the working directory
+// using the codex-auth configuration. It reports false when no .codex-profile
+// applies or the bound profile is already installed.
+func InstallProjectProfile(log *logger.Logger) (string, bool, error) {
+	binding, ok, err := CurrentBinding()
+	if err != nil || !ok {
+		return "", false, err
+	}
+	s, err := load(log)
+	if err != nil {
+		return "", false, err
+	}
+	files, err := auth.ListRoots(s.Roots)
+	if err != nil {
+		return "", false, err
+	}
+	file, err := binding.Resolve(files)
+	if err != nil {
+		return "", false, err
+	}
+	result, err := s.InstallBound(binding, file, "codex-yolo auto-profile")
+	if err != nil {
+		return file.Name, false, err
+	}
+	return file.Name, result.Installed, nil
+}
+
+// PrepareIsolatedHome installs profile into its own Codex home and points
+// CODEX_HOME at it, so Codex processes started afterwards use that account.
+// An empty profile falls back to the .codex-profile binding, which must name
+// the profile exactly. It returns the matched profile name and the home
+// directory.
+func PrepareIsolatedHome(log *logger.Logger, profile string) (string, string, error) {
+	binding := auth.ProjectBinding{Profile: profile}
+	if profile == "" {
+		var ok bool
+		var err error
+		binding, ok, err = CurrentBinding()
+		if err != nil {
+			return "", "", err
+		}
+		if !ok {
+			return "", "", fmt.Errorf("no profile given and no %s found", auth.ProjectFileName)
+		}
+	}
+	s, err := load(log)
+	if err != nil {
+		return "", "", err
+	}
+	s.Isolate = true
+	files, err := s.ValidFiles()
+	if err != nil {
+		return "", "", err
+	}
+	var file auth.File
+	if profile != "" {
+		file, err = auth.Match(files, profile)
+	} else {
+		file, err = binding.Resolve(files)
+	}
+	if err != nil {
+		return "", "", err
+	}
+	if _, err := s.InstallBound(binding, file, "codex-yolo isolate"); err != nil {
+		return file.Name, "", err
+	}
+	home, err := auth.CodexDir()
+	return file.Name, home, err
+}
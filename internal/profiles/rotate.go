@@ -0,0 +1,79 @@
+package profiles
+
+import (
+	"context"
+	"fmt"
+
+	"codex-control/internal/auth"
+	"codex-control/internal/logger"
+)
+
+// Rotator picks and installs the next eligible profile when Codex hits a
+// usage limit. Profiles are tried from least to most recently used and each
+// one at most once per Rotator.
+type Rotator struct {
+	switcher *Switcher
+	pool     []string
+	tried    map[string]struct{}
+}
+
+// NewRotator prepares a rotation over pool (all profiles when empty) using
+// the codex-auth configuration.
+func NewRotator(log *logger.Logger, pool []string) (*Rotator, error) {
+	s, err := load(log)
+	if err != nil {
+		return nil, err
+	}
+	// The resumed session lives in the current Codex home, so rotation always
+	// installs there even when profiles are normally isolated.
+	s.Isolate = false
+	return &Rotator{switcher: s, pool: pool, tried: map[string]struct{}{}}, nil
+}
+
+// Next installs the next eligible profile and returns its name. It reports
+// false when every profile in the pool has been tried.
+func (r *Rotator) Next(_ context.Context) (string, bool, error) {
+	files, err := r.candidates()
+	if err != nil {
+		return "", false, err
+	}
+	if state, ok, err := auth.LoadInstallState(); err == nil && ok {
+		r.tried[state.Source] = struct{}{}
+	}
+	SortByLastUsed(files, r.switcher.Tracker)
+	for _, file := range files {
+		if _, done := r.tried[file.Path]; done || file.Invalid != "" || file.Meta.Disabled || file.LeasedByOther() {
+			continue
+		}
+		r.tried[file.Path] = struct{}{}
+		if _, err := r.switcher.Activate(file, "codex-yolo rotate"); err != nil {
+			return file.Name, false, err
+		}
+		return file.Name, true, nil
+	}
+	return "", false, nil
+}
+
+func (r *Rotator) candidates() ([]auth.File, error) {
+	files, err := auth.ListRoots(r.switcher.Roots)
+	if err != nil {
+		return nil, err
+	}
+	if len(r.pool) == 0 {
+		return files, nil
+	}
+	selected := make([]auth.File, 0, len(r.pool))
+	seen := map[string]struct{}{}
+	for _, name := range r.pool {
+		file, err := auth.Match(files, name)
+		if err != nil {
+			return nil, fmt.Errorf("rotation pool: %w", err)
+		}
+		if _, dup := seen[file.Path]; dup {
+			continue
+		}
+		seen[file.Path] = struct{}{}
+		selected = append(selected, file)
+	}
+	return selected, nil
+}
@@ -0,0 +1,196 @@
+package profiles
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"codex-control/internal/auth"
+	"codex-control/internal/config"
+	"codex-control/internal/logger"
+)
+
+// Switcher installs profiles from the configured auths roots and keeps the
+// usage data, leases and isolated homes that go with a switch in step.
+type Switcher struct {
+	Log *logger.Logger
+	// Root stores new profiles and the usage data; Roots lists every folder
+	// profiles are read from, Root first.
+	Root       string
+	Roots      []string
+	Tracker    *auth.UsageTracker
+	Passphrase Passphrase
+	Install    auth.InstallOptions
+	// Isolate installs each profile into its own Codex home under HomesDir,
+	// sharing configuration with SharedHome.
+	Isolate    bool
+	HomesDir   string
+	SharedHome string
+	LeaseTTL   time.Duration
+	// Force takes over leases held by someone else.
+	Force bool
+}
+
+// New resolves the auth directories and usage data of settings. saveRoot
+// accepts an empty first auth directory so the first profile can be saved
+// into it.
+func New(log *logger.Logger, settings Config, saveRoot bool) (*Switcher, error) {
+	if err := auth.SetIgnorePatterns(settings.Ignore); err != nil {
+		return nil, err
+	}
+	validateRoot := auth.ValidateRoot
+	if saveRoot {
+		validateRoot = auth.ValidateSaveRoot
+	}
+	if len(settings.AuthsPath) == 0 {
+		settings.AuthsPath = config.StringList{""}
+	}
+	roots := make([]string, 0, len(settings.AuthsPath))
+	for i, path := range settings.AuthsPath {
+		validate := auth.ValidateRoot
+		if i == 0 {
+			validate = validateRoot
+		}
+		root, err := validate(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid auth directory: %w", err)
+		}
+		roots = append(roots, root)
+	}
+	authPath := roots[0]
+	tracker, err := auth.LoadUsageTracker(authPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load auth usage data: %w", err)
+	}
+	passphrase := Passphrase{File: settings.PassphraseFile}
+	homes := settings.HomesPath
+	if homes == "" {
+		if homes, err = auth.DefaultHomesDir(); err != nil {
+			return nil, err
+		}
+	}
+	shared, err := auth.CodexDir()
+	if err != nil {
+		return nil, err
+	}
+	leaseTTL, err := ParseLeaseTTL(settings.LeaseTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &Switcher{
+		Log:        log,
+		Root:       authPath,
+		Roots:      roots,
+		Tracker:    tracker,
+		Passphrase: passphrase,
+		Install:    auth.InstallOptions{BackupLimit: settings.BackupLimit, Passphrase: passphrase.Func(false)},
+		Isolate:    settings.Isolate,
+		HomesDir:   homes,
+		SharedHome: shared,
+		LeaseTTL:   leaseTTL,
+	}, nil
+}
+
+// load builds a Switcher from the codex-auth config file, for tools that
+// switch profiles without their own auth settings.
+func load(log *logger.Logger) (*Switcher, error) {
+	settings, err := LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load codex-auth config: %w", err)
+	}
+	return New(log, settings, false)
+}
+
+// Activate installs the auth file and records the switch, attributing it to
+// trigger in the usage history. Refreshed tokens of the outgoing profile are
+// synced back right before the install so they survive. In isolation mode the
+// profile goes into its own Codex home, and that home is the one synced.
+func (s *Switcher) Activate(file auth.File, trigger string) (auth.CopyResult, error) {
+	if s.Isolate {
+		if _, err := s.EnterHome(file); err != nil {
+			return auth.CopyResult{}, err
+		}
+	}
+	previous, _, err := auth.LoadInstallState()
+	if err != nil {
+		return auth.CopyResult{}, err
+	}
+	if err := s.Lease(file.Path); err != nil {
+		return auth.CopyResult{}, err
+	}
+	s.SyncBeforeSwitch()
+	result, err := auth.Install(file.Path, s.Install)
+	if err != nil {
+		if previous.Source != file.Path {
+			s.ReleaseLease(file.Path)
+		}
+		return result, err
+	}
+	if previous.Source != "" && previous.Source != file.Path {
+		s.ReleaseLease(previous.Source)
+	}
+	if s.Tracker != nil {
+		_ = s.Tracker.Record(s.Tracker.Key(file), trigger, time.Now())
+	}
+	return result, nil
+}
+
+// EnterHome prepares the isolated Codex home of file, sharing configuration
+// with the home the tool started in, and points CODEX_HOME at it.
+func (s *Switcher) EnterHome(file auth.File) (string, error) {
+	home, err := auth.IsolatedHome(s.HomesDir, file, s.SharedHome)
+	if err != nil {
+		return "", fmt.Errorf("failed to prepare Codex home for %s: %w", file.Name, err)
+	}
+	return home, auth.SetCodexHome(home)
+}
+
+// SyncBeforeSwitch saves refreshed tokens of the outgoing profile. Failures
+// are reported but never block the switch itself.
+func (s *Switcher) SyncBeforeSwitch() {
+	result, err := auth.Sync(s.Install)
+	if err != nil {
+		s.Log.Errorf(logger.PrefixAuth, "Sync before switching failed: %v", err)
+		return
+	}
+	if result.Status == auth.SyncConflict {
+		s.Log.Errorf(logger.PrefixAuth, "Not syncing %s back: %s", result.Profile, result.Detail)
+	}
+}
+
+// ValidFiles lists the profiles of every root, leaving out invalid files.
+func (s *Switcher) ValidFiles() ([]auth.File, error) {
+	files, err := auth.ListRoots(s.Roots)
+	if err != nil {
+		return nil, err
+	}
+	valid := make([]auth.File, 0, len(files))
+	for _, file := range files {
+		if file.Invalid == "" {
+			valid = append(valid, file)
+		}
+	}
+	return valid, nil
+}
+
+// SortByLastUsed orders files from least to most recently used, with never
+// used files first.
+func SortByLastUsed(files []auth.File, tracker *auth.UsageTracker) {
+	sort.SliceStable(files, func(i, j int) bool {
+		ti := tracker.LastUsed(tracker.Key(files[i]))
+		tj := tracker.LastUsed(tracker.Key(files[j]))
+		if ti.IsZero() && tj.IsZero() {
+			return files[i].Name < files[j].Name
+		}
+		if ti.IsZero() {
+			return true
+		}
+		if tj.IsZero() {
+			return false
+		}
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		return files[i].Name < files[j].Name
+	})
+}
@@ -3,6 +3,8 @@ package menu
 import (
 	"context"
 	"fmt"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -16,6 +18,7 @@ type viewMode int
 const (
 	viewList viewMode = iota
 	viewActions
+	viewPrompt
 )
 
 // Entry represents a selectable row in the menu.
@@ -25,7 +28,14 @@ type Entry struct {
 	Subtitle    string
 	Description string
 	Badges      []string
-	Payload     any
+	// Tags are offered as list filters cycled with the T key.
+	Tags []string
+	// Color optionally tints the title (any lipgloss color string).
+	Color string
+	// Key identifies the entry across reloads so the cursor can follow it.
+	// The title is used when it is empty.
+	Key     string
+	Payload any
 }
 
 // Action executes when a user chooses an entry inside the action menu.
@@ -46,6 +56,9 @@ type Config struct {
 	Loader           func(context.Context) ([]Entry, error)
 	Actions          []Action
 	DisablePanel     bool
+	// Watch, when set, runs while the menu is open and calls notify whenever
+	// the entries should be reloaded.
+	Watch func(ctx context.Context, notify func()) error
 }
 
 // Result summarizes the completed interaction.
@@ -73,6 +86,15 @@ func Start(cfg Config) (Result, error) {
 		panelTitle: "Information",
 	}
 	p := tea.NewProgram(m)
+	if cfg.Watch != nil {
+		ctx, cancel := context.WithCancel(cfg.Context)
+		defer cancel()
+		go func() {
+			if err := cfg.Watch(ctx, func() { p.Send(rescanMsg{}) }); err != nil {
+				p.Send(statusMsg{text: fmt.Sprintf("Live rescan unavailable: %v", err)})
+			}
+		}()
+	}
 	final, err := p.Run()
 	if err != nil {
 		return Result{}, err
@@ -87,7 +109,9 @@ func Start(cfg Config) (Result, error) {
 type model struct {
 	cfg Config
 
+	allEntries   []Entry
 	entries      []Entry
+	tagFilter    string
 	view         viewMode
 	width        int
 	height       int
@@ -100,8 +124,24 @@ type model struct {
 	panelText   string
 	numberInput string
 	loading     bool
+	keepMessage bool
+	// rescanning is set while a rescan triggered by Watch runs; rescanPending
+	// records changes reported meanwhile so they are loaded afterwards.
+	rescanning    bool
+	rescanPending bool
 
 	lastAction *actionState
+	prompt     *promptState
+}
+
+// promptState backs the prompt view, which either reads a line of text or
+// asks for a yes/no confirmation.
+type promptState struct {
+	question string
+	value    string
+	confirm  bool
+	submit   func(string) tea.Cmd
+	yes      tea.Cmd
 }
 
 type actionState struct {
@@ -114,6 +154,20 @@ type actionState struct {
 type entriesLoadedMsg struct {
 	entries []Entry
 	err     error
+	rescan  bool
+}
+
+type refreshMsg struct{}
+
+// rescanMsg reloads the entries in the background after a change on disk.
+type rescanMsg struct{}
+
+type promptMsg struct {
+	state promptState
+}
+
+type statusMsg struct {
+	text string
 }
 
 type panelMsg struct {
@@ -137,21 +191,55 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 	case entriesLoadedMsg:
 		m.loading = false
+		if msg.rescan {
+			m.rescanning = false
+		}
 		if msg.err != nil {
 			m.message = fmt.Sprintf("Failed to load entries: %v", msg.err)
-			return m, nil
-		}
-		m.entries = msg.entries
-		for i := range m.entries {
-			m.entries[i].Number = i + 1
+			cmd := m.pendingRescanCmd()
+			return m, cmd
 		}
+		selected := entryKey(m.currentEntryValue())
+		m.allEntries = msg.entries
+		m.applyFilter()
+		found := m.selectKey(selected)
 		if len(m.entries) == 0 {
 			m.listCursor = 0
 		} else if m.listCursor >= len(m.entries) {
 			m.listCursor = len(m.entries) - 1
 		}
 		m.ensureListCursorVisible()
-		m.message = fmt.Sprintf("Loaded %d entries", len(m.entries))
+		switch {
+		case msg.rescan && !found && m.view != viewList:
+			m.view = viewList
+			m.prompt = nil
+			m.message = "The selected entry disappeared from disk"
+		case msg.rescan:
+			m.message = fmt.Sprintf("Reloaded %d entries after a change on disk", len(m.entries))
+		case m.keepMessage:
+			m.keepMessage = false
+		default:
+			m.message = fmt.Sprintf("Loaded %d entries", len(m.entries))
+		}
+		cmd := m.pendingRescanCmd()
+		return m, cmd
+	case rescanMsg:
+		m.rescanPending = true
+		cmd := m.pendingRescanCmd()
+		return m, cmd
+	case refreshMsg:
+		m.view = viewList
+		m.actionCursor = 0
+		m.loading = true
+		m.keepMessage = true
+		return m, m.loadEntriesCmd()
+	case promptMsg:
+		state := msg.state
+		m.prompt = &state
+		m.view = viewPrompt
+		return m, nil
+	case statusMsg:
+		m.message = msg.text
 		return m, nil
 	case panelMsg:
 		m.panelTitle = msg.title
@@ -174,6 +262,9 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.view == viewPrompt && m.prompt != nil {
+		return m.handlePromptKey(msg)
+	}
 	switch msg.String() {
 	case "ctrl+c":
 		return m, tea.Quit
@@ -239,6 +330,20 @@ func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			return m, action.Exec(entry)
 		}
 		return m, nil
+	case "t", "T":
+		if m.view == viewList {
+			m.clearNumberInput()
+			m.tagFilter = nextTag(m.allEntries, m.tagFilter)
+			m.applyFilter()
+			m.listCursor = 0
+			m.listOffset = 0
+			if m.tagFilter == "" {
+				m.message = "Showing all entries"
+			} else {
+				m.message = fmt.Sprintf("Filtering by tag %q", m.tagFilter)
+			}
+		}
+		return m, nil
 	case "r", "R":
 		if m.view == viewList {
 			m.loading = true
@@ -264,6 +369,102 @@ func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+func (m model) handlePromptKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	prompt := m.prompt
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		m.closePrompt("Cancelled")
+		return m, nil
+	}
+	if prompt.confirm {
+		switch msg.String() {
+		case "y", "Y":
+			m.closePrompt("")
+			return m, prompt.yes
+		case "n", "N", "enter":
+			m.closePrompt("Cancelled")
+		}
+		return m, nil
+	}
+	switch msg.Type {
+	case tea.KeyEnter:
+		m.closePrompt("")
+		return m, prompt.submit(strings.TrimSpace(prompt.value))
+	case tea.KeyBackspace:
+		if runes := []rune(prompt.value); len(runes) > 0 {
+			prompt.value = string(runes[:len(runes)-1])
+		}
+	case tea.KeyCtrlU:
+		prompt.value = ""
+	case tea.KeySpace:
+		prompt.value += " "
+	case tea.KeyRunes:
+		prompt.value += string(msg.Runes)
+	}
+	return m, nil
+}
+
+// closePrompt leaves the prompt view for the actions view, optionally setting
+// the status message.
+func (m *model) closePrompt(message string) {
+	m.prompt = nil
+	m.view = viewActions
+	if message != "" {
+		m.message = message
+	}
+}
+
+// applyFilter rebuilds the visible entries from the loaded ones, dropping the
+// tag filter when no entry carries the tag anymore.
+func (m *model) applyFilter() {
+	if m.tagFilter != "" && !slices.Contains(collectTags(m.allEntries), m.tagFilter) {
+		m.tagFilter = ""
+	}
+	m.entries = make([]Entry, 0, len(m.allEntries))
+	for _, entry := range m.allEntries {
+		if m.tagFilter != "" && !slices.Contains(entry.Tags, m.tagFilter) {
+			continue
+		}
+		entry.Number = len(m.entries) + 1
+		m.entries = append(m.entries, entry)
+	}
+}
+
+func collectTags(entries []Entry) []string {
+	seen := map[string]struct{}{}
+	tags := []string{}
+	for _, entry := range entries {
+		for _, tag := range entry.Tags {
+			if _, ok := seen[tag]; ok {
+				continue
+			}
+			seen[tag] = struct{}{}
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// nextTag cycles through the available tags, returning "" for "all entries"
+// after the last one.
+func nextTag(entries []Entry, current string) string {
+	tags := collectTags(entries)
+	if len(tags) == 0 {
+		return ""
+	}
+	if current == "" {
+		return tags[0]
+	}
+	idx := slices.Index(tags, current)
+	if idx < 0 || idx+1 >= len(tags) {
+		return ""
+	}
+	return tags[idx+1]
+}
+
 func (m *model) clearNumberInput() {
 	m.numberInput = ""
 }
@@ -327,6 +528,48 @@ func (m model) currentEntryValue() Entry {
 	return m.entries[m.listCursor]
 }
 
+// selectKey moves the cursor to the entry with the given key and reports
+// whether it is still listed.
+func (m *model) selectKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i, entry := range m.entries {
+		if entryKey(entry) == key {
+			m.listCursor = i
+			return true
+		}
+	}
+	return false
+}
+
+func entryKey(entry Entry) string {
+	if entry.Key != "" {
+		return entry.Key
+	}
+	return entry.Title
+}
+
+// pendingRescanCmd starts a requested rescan once no load is running, so a
+// change reported during a load is picked up when it finishes.
+func (m *model) pendingRescanCmd() tea.Cmd {
+	if !m.rescanPending || m.loading || m.rescanning {
+		return nil
+	}
+	m.rescanPending = false
+	m.rescanning = true
+	return m.rescanCmd()
+}
+
+func (m model) rescanCmd() tea.Cmd {
+	load := m.loadEntriesCmd()
+	return func() tea.Msg {
+		msg := load().(entriesLoadedMsg)
+		msg.rescan = true
+		return msg
+	}
+}
+
 func (m model) loadEntriesCmd() tea.Cmd {
 	return func() tea.Msg {
 		ctx, cancel := context.WithTimeout(m.cfg.Context, m.cfg.LoadTimeout)
@@ -342,6 +585,8 @@ func (m model) View() string {
 		return m.renderList()
 	case viewActions:
 		return m.renderActions()
+	case viewPrompt:
+		return m.renderPrompt()
 	default:
 		return ""
 	}
@@ -406,7 +651,11 @@ func (m model) renderList() string {
 		badges := formatBadges(entry.Badges)
 		titleText := entry.Title
 		if titleText != "" {
-			titleText = entryTitleStyle.Render(titleText)
+			style := entryTitleStyle
+			if entry.Color != "" {
+				style = style.Foreground(lipgloss.Color(entry.Color))
+			}
+			titleText = style.Render(titleText)
 		}
 		summary := entry.Description
 		if summary == "" {
@@ -433,6 +682,13 @@ func (m model) renderList() string {
 	if m.numberInput != "" {
 		left.WriteString(messageStyle.Render(fmt.Sprintf("Pending selection: %s", m.numberInput)) + "\n")
 	}
+	if tags := collectTags(m.allEntries); len(tags) > 0 {
+		filter := "all"
+		if m.tagFilter != "" {
+			filter = m.tagFilter
+		}
+		left.WriteString(messageStyle.Render(fmt.Sprintf("Tag filter: %s (press T to cycle)", filter)) + "\n")
+	}
 	left.WriteString(messageStyle.Render(fmt.Sprintf("Status: %s", m.message)))
 	if m.cfg.DisablePanel {
 		return left.String()
@@ -476,6 +732,32 @@ func (m model) renderActions() string {
 	return lipgloss.JoinHorizontal(lipgloss.Top, left.String(), right)
 }
 
+func (m model) renderPrompt() string {
+	entry := m.currentEntryValue()
+	var b strings.Builder
+	title := m.cfg.ActionsTitle
+	if title == "" {
+		title = "Actions"
+	}
+	b.WriteString(titleStyle.Render(title) + "\n")
+	if entry.Title != "" {
+		b.WriteString(fmt.Sprintf("Target: %s\n", entry.Title))
+	}
+	b.WriteString("\n")
+	if m.prompt != nil {
+		b.WriteString(m.prompt.question + "\n")
+		if m.prompt.confirm {
+			b.WriteString(messageStyle.Render("Press y to confirm, n or Esc to cancel.") + "\n")
+		} else {
+			b.WriteString(prefixActive.Render(" › ") + m.prompt.value + "█\n")
+			b.WriteString(messageStyle.Render("Enter submits, Ctrl+U clears, Esc cancels.") + "\n")
+		}
+	}
+	b.WriteString("\n")
+	b.WriteString(messageStyle.Render(fmt.Sprintf("Status: %s", m.message)))
+	return b.String()
+}
+
 func (m model) renderPanel() string {
 	if m.cfg.DisablePanel {
 		return ""
@@ -512,6 +794,33 @@ func PanelUpdate(title, content string, payload any, err error) tea.Msg {
 	return panelMsg{title: title, content: content, payload: payload, err: err}
 }
 
+// Refresh returns to the list view and reloads the entries while keeping the
+// current status message. Actions sequence it after their own command.
+func Refresh() tea.Msg {
+	return refreshMsg{}
+}
+
+// Prompt asks for a line of text, prefilled with value, and runs submit with
+// the trimmed answer. Esc cancels back to the actions.
+func Prompt(question, value string, submit func(string) tea.Cmd) tea.Cmd {
+	return func() tea.Msg {
+		return promptMsg{state: promptState{question: question, value: value, submit: submit}}
+	}
+}
+
+// Confirm asks a yes/no question and runs yes only when it is accepted.
+func Confirm(question string, yes tea.Cmd) tea.Cmd {
+	return func() tea.Msg {
+		return promptMsg{state: promptState{question: question, confirm: true, yes: yes}}
+	}
+}
+
+// Status replaces the status line without recording an action result, for
+// actions that should not end the menu successfully.
+func Status(text string) tea.Msg {
+	return statusMsg{text: text}
+}
+
 func (m model) toResult() Result {
 	if m.lastAction == nil {
 		return Result{Success: false, Message: "no action executed"}
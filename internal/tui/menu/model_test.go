@@ -0,0 +1,62 @@
+package menu
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRescanDuringLoadIsNotDropped(t *testing.T) {
+	loads := 0
+	m := model{
+		cfg: Config{
+			Context:     context.Background(),
+			LoadTimeout: time.Second,
+			Loader: func(context.Context) ([]Entry, error) {
+				loads++
+				return []Entry{{Title: "work", Key: "work"}}, nil
+			},
+		},
+		view:    viewList,
+		loading: true,
+	}
+
+	// A change on disk is reported while the initial load is still running.
+	next, cmd := m.Update(rescanMsg{})
+	m = next.(model)
+	if cmd != nil {
+		t.Fatal("rescan started while entries were loading")
+	}
+
+	next, cmd = m.Update(entriesLoadedMsg{})
+	m = next.(model)
+	if cmd == nil {
+		t.Fatal("pending rescan did not run after the load finished")
+	}
+	msg := cmd()
+	if loaded, ok := msg.(entriesLoadedMsg); !ok || !loaded.rescan {
+		t.Fatalf("follow-up command returned %#v, want a rescan", msg)
+	}
+	if loads != 1 {
+		t.Fatalf("loader ran %d times, want 1", loads)
+	}
+
+	// Further changes during that rescan are folded into one more rescan.
+	for range 2 {
+		next, cmd = m.Update(rescanMsg{})
+		m = next.(model)
+		if cmd != nil {
+			t.Fatal("second rescan started while one was running")
+		}
+	}
+	next, cmd = m.Update(msg)
+	m = next.(model)
+	if cmd == nil {
+		t.Fatal("changes reported during the rescan were dropped")
+	}
+	next, cmd = m.Update(cmd())
+	m = next.(model)
+	if cmd != nil || m.rescanning || m.rescanPending {
+		t.Fatalf("rescan did not settle: cmd=%v rescanning=%v pending=%v", cmd != nil, m.rescanning, m.rescanPending)
+	}
+}
@@ -0,0 +1,143 @@
+package yolo
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// limitWatcher scans Codex session logs for usage-limit errors raised after a
+// given start time. Offsets are remembered so repeated checks only read new
+// lines, and logs that already existed at the start are read from their end.
+type limitWatcher struct {
+	dir     string
+	since   time.Time
+	offsets map[string]int64
+}
+
+type sessionEvent struct {
+	Type    string `json:"type"`
+	Payload struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"payload"`
+}
+
+// newLimitWatcher watches logs written since the given time. The start is
+// truncated to whole seconds because file times may be coarser.
+func newLimitWatcher(sessionsDir string, since time.Time) *limitWatcher {
+	w := &limitWatcher{dir: sessionsDir, since: since.Truncate(time.Second), offsets: map[string]int64{}}
+	w.eachLog(func(path string, info fs.FileInfo) error {
+		w.offsets[path] = info.Size()
+		return nil
+	})
+	return w
+}
+
+// Check reports whether any session log written since the start contains a
+// usage-limit error event.
+func (w *limitWatcher) Check() (bool, error) {
+	hit := false
+	err := w.eachLog(func(path string, info fs.FileInfo) error {
+		if hit || info.ModTime().Before(w.since) {
+			return nil
+		}
+		found, err := w.scan(path)
+		hit = found
+		return err
+	})
+	return hit, err
+}
+
+func (w *limitWatcher) eachLog(fn func(path string, info fs.FileInfo) error) error {
+	if w.dir == "" {
+		return nil
+	}
+	for _, dir := range w.dayDirs() {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return err
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".jsonl") {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			if err := fn(filepath.Join(dir, entry.Name()), info); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// dayDirs lists the sessions/YYYY/MM/DD folders that can hold logs written
+// since the start, covering both local and UTC dates.
+func (w *limitWatcher) dayDirs() []string {
+	seen := map[string]struct{}{}
+	dirs := []string{}
+	for day := w.since.Add(-24 * time.Hour); !day.After(time.Now().Add(24 * time.Hour)); day = day.Add(24 * time.Hour) {
+		for _, t := range []time.Time{day.Local(), day.UTC()} {
+			dir := filepath.Join(w.dir, t.Format("2006"), t.Format("01"), t.Format("02"))
+			if _, ok := seen[dir]; ok {
+				continue
+			}
+			seen[dir] = struct{}{}
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+func (w *limitWatcher) scan(path string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+	offset := w.offsets[path]
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return false, err
+	}
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// Keep partial lines for the next check.
+			break
+		}
+		if err != nil {
+			return false, err
+		}
+		offset += int64(len(line))
+		w.offsets[path] = offset
+		if isLimitEvent(line) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func isLimitEvent(line []byte) bool {
+	var event sessionEvent
+	if err := json.Unmarshal(line, &event); err != nil {
+		return false
+	}
+	if event.Payload.Type != "error" {
+		return false
+	}
+	message := strings.ToLower(event.Payload.Message)
+	return strings.Contains(message, "usage limit")
+}
@@ -5,7 +5,9 @@ import (
 	"errors"
 	"os"
 	"os/exec"
+	"slices"
 	"strings"
+	"time"
 
 	"codex-control/internal/logger"
 )
@@ -18,41 +20,159 @@ const (
 	ModeResume  Mode = "resume"
 )
 
+// limitPollInterval is how often session logs are checked while Codex runs.
+const limitPollInterval = 3 * time.Second
+
 // Runner executes Codex with the bypass flags.
 type Runner struct {
 	Binary string
 	Mode   Mode
 	Log    *logger.Logger
+
+	// Rotate installs another account after Codex hit its usage limit and
+	// returns the profile name, or false when no profile is eligible. A nil
+	// Rotate disables usage-limit handling.
+	Rotate func(ctx context.Context) (string, bool, error)
+	// MaxRotations caps how many times a single run switches accounts.
+	MaxRotations int
+	// SessionsDir is the Codex sessions folder watched for usage-limit events.
+	SessionsDir string
+	// LimitExitCodes are Codex exit statuses that also count as a usage limit.
+	LimitExitCodes []int
+	// Environment returns extra NAME=value variables and leading Codex
+	// arguments for the installed profile. It runs before every launch so a
+	// rotated profile brings its own settings.
+	Environment func() (env []string, args []string, err error)
 }
 
 // Result describes the proxied command run.
 type Result struct {
-	Command  []string `json:"command"`
-	ExitCode int      `json:"exit_code"`
+	Command   []string `json:"command"`
+	ExitCode  int      `json:"exit_code"`
+	Rotations []string `json:"rotations,omitempty"`
 }
 
-// Run executes the Codex command and returns its exit status.
+// Run executes the Codex command and returns its exit status. With rotation
+// enabled, a usage limit switches to the next profile and resumes the last
+// session in ModeResume with the original arguments until MaxRotations is
+// reached.
 func (r Runner) Run(ctx context.Context, args []string) (Result, error) {
 	binary := r.Binary
 	if binary == "" {
 		binary = "codex"
 	}
-	cmdArgs := r.buildArgs(args)
+	mode := r.Mode
+	original := args
+	var rotations []string
+	for {
+		var env, overrides []string
+		if r.Environment != nil {
+			var envErr error
+			if env, overrides, envErr = r.Environment(); envErr != nil {
+				return Result{ExitCode: 1, Rotations: rotations}, envErr
+			}
+		}
+		cmdArgs := slices.Concat(overrides, buildArgs(mode, args))
+		result, limited, err := r.runOnce(ctx, binary, cmdArgs, env)
+		result.Rotations = rotations
+		if !limited || ctx.Err() != nil {
+			return result, err
+		}
+		if len(rotations) >= r.MaxRotations {
+			r.logf("Usage limit reached and rotation cap (%d) exhausted", r.MaxRotations)
+			return result, err
+		}
+		profile, ok, rotateErr := r.Rotate(ctx)
+		if rotateErr != nil {
+			r.logf("Usage limit reached but rotation failed: %v", rotateErr)
+			return result, err
+		}
+		if !ok {
+			r.logf("Usage limit reached and no other profile is eligible")
+			return result, err
+		}
+		rotations = append(rotations, profile)
+		r.logf("Usage limit reached; switched to %s and resuming the last session", profile)
+		mode = ModeResume
+		args = resumeArgs(r.Mode, original)
+	}
+}
+
+// resumeArgs returns the arguments that continue the interrupted session after
+// a rotation. The original arguments, such as the model or config overrides,
+// are kept and --last is added unless they already name the session to
+// resume.
+func resumeArgs(mode Mode, args []string) []string {
+	if (mode == ModeResume && len(args) > 0) || slices.Contains(args, "--last") {
+		return args
+	}
+	return append([]string{"--last"}, args...)
+}
+
+// runOnce launches Codex and reports whether it stopped because of a usage
+// limit. While Codex runs the session logs are polled and Codex is
+// interrupted as soon as a limit event shows up.
+func (r Runner) runOnce(ctx context.Context, binary string, cmdArgs, env []string) (Result, bool, error) {
+	command := append([]string{binary}, cmdArgs...)
 	if r.Log != nil {
-		r.Log.Printf(logger.PrefixCodex, "Executing %s", strings.Join(append([]string{binary}, cmdArgs...), " "))
+		r.Log.Printf(logger.PrefixCodex, "Executing %s", strings.Join(command, " "))
 	}
 	cmd := exec.CommandContext(ctx, binary, cmdArgs...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	cmd.Stdin = os.Stdin
-	err := cmd.Run()
-	exit := exitCode(err)
-	return Result{Command: append([]string{binary}, cmdArgs...), ExitCode: exit}, err
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	if r.Rotate == nil {
+		err := cmd.Run()
+		return Result{Command: command, ExitCode: exitCode(err)}, false, err
+	}
+
+	watcher := newLimitWatcher(r.SessionsDir, time.Now())
+	if err := cmd.Start(); err != nil {
+		return Result{Command: command, ExitCode: exitCode(err)}, false, err
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	ticker := time.NewTicker(limitPollInterval)
+	defer ticker.Stop()
+	limited := false
+	for {
+		select {
+		case err := <-done:
+			if !limited {
+				limited, _ = watcher.Check()
+			}
+			exit := exitCode(err)
+			if !limited && slices.Contains(r.LimitExitCodes, exit) {
+				limited = true
+			}
+			return Result{Command: command, ExitCode: exit}, limited, err
+		case <-ticker.C:
+			if limited {
+				continue
+			}
+			hit, err := watcher.Check()
+			if err != nil || !hit {
+				continue
+			}
+			limited = true
+			r.logf("Usage limit detected in the session log; stopping Codex")
+			_ = cmd.Process.Signal(os.Interrupt)
+		}
+	}
+}
+
+func (r Runner) logf(format string, args ...any) {
+	if r.Log != nil {
+		r.Log.Printf(logger.PrefixCodex, format, args...)
+	}
 }
 
-func (r Runner) buildArgs(args []string) []string {
+func buildArgs(mode Mode, args []string) []string {
 	base := []string{"--dangerously-bypass-approvals-and-sandbox"}
-	if r.Mode == ModeResume {
+	if mode == ModeResume {
 		base = append([]string{"resume"}, base...)
 	}
 	return append(base, args...)
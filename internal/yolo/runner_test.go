@@ -0,0 +1,36 @@
+package yolo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResumeArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		mode Mode
+		args []string
+		want []string
+	}{
+		{name: "no arguments", mode: ModeDefault, want: []string{"--last"}},
+		{name: "keeps options and prompt", mode: ModeDefault, args: []string{"-m", "o3", "fix the tests"}, want: []string{"--last", "-m", "o3", "fix the tests"}},
+		{name: "already last", mode: ModeDefault, args: []string{"--last", "-m", "o3"}, want: []string{"--last", "-m", "o3"}},
+		{name: "resume without session", mode: ModeResume, want: []string{"--last"}},
+		{name: "resume named session", mode: ModeResume, args: []string{"0199a1b2", "-m", "o3"}, want: []string{"0199a1b2", "-m", "o3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resumeArgs(tt.mode, tt.args); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("resumeArgs = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildArgsResume(t *testing.T) {
+	got := buildArgs(ModeResume, resumeArgs(ModeDefault, []string{"-c", "model=o3"}))
+	want := []string{"resume", "--dangerously-bypass-approvals-and-sandbox", "--last", "-c", "model=o3"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("buildArgs = %q, want %q", got, want)
+	}
+}